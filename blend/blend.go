@@ -1,6 +1,7 @@
 package blend
 
 import (
+	"context"
 	"errors"
 	"image"
 	"image/color"
@@ -14,15 +15,27 @@ import (
 //
 //	res := blend.AddScalarToGray(img, 56)
 func AddScalarToGray(img *image.Gray, value int) *image.Gray {
+	res, _ := AddScalarToGrayCtx(context.Background(), img, value, nil)
+	return res
+}
+
+// AddScalarToGrayCtx is AddScalarToGray which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func AddScalarToGrayCtx(ctx context.Context, img *image.Gray, value int,
+	opts *utils.Options) (*image.Gray, error) {
 	size := img.Bounds().Size()
+	offset := img.Bounds().Min
 	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
 
-	utils.ForEachGrayPixel(img, func(pixel color.Gray, x, y int) {
-		updatedPixel := int(pixel.Y) + value
+	err := utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		updatedPixel := int(img.GrayAt(x+offset.X, y+offset.Y).Y) + value
 		res.SetGray(x, y, color.Gray{Y: uint8(utils.ClampInt(updatedPixel, utils.MinUint8, int(utils.MaxUint8)))})
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return res
+	return res, nil
 }
 
 // AddGray accepts two grayscale images and adds their pixel values. If the result for a given position overflows uint8,
@@ -31,6 +44,12 @@ func AddScalarToGray(img *image.Gray, value int) *image.Gray {
 //
 //	res, err := blend.AddGray(gray1, gray2)
 func AddGray(img1 *image.Gray, img2 *image.Gray) (*image.Gray, error) {
+	return AddGrayCtx(context.Background(), img1, img2, nil)
+}
+
+// AddGrayCtx is AddGray which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func AddGrayCtx(ctx context.Context, img1 *image.Gray, img2 *image.Gray,
+	opts *utils.Options) (*image.Gray, error) {
 	size1 := img1.Bounds().Size()
 	size2 := img2.Bounds().Size()
 	if size1.X != size2.X || size1.Y != size2.Y {
@@ -41,12 +60,15 @@ func AddGray(img1 *image.Gray, img2 *image.Gray) (*image.Gray, error) {
 	o2 := img2.Bounds().Min
 
 	res := image.NewGray(image.Rect(0, 0, size1.X, size1.Y))
-	utils.IteratePixels(size1, func(x, y int) {
+	err := utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size1, func(x, y int) {
 		p1 := img1.GrayAt(x+o1.X, y+o1.Y)
 		p2 := img2.GrayAt(x+o2.X, y+o2.Y)
 		sum := utils.ClampInt(int(p1.Y)+int(p2.Y), utils.MinUint8, int(utils.MaxUint8))
 		res.SetGray(x, y, color.Gray{Y: uint8(sum)})
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return res, nil
 }
@@ -59,6 +81,13 @@ func AddGray(img1 *image.Gray, img2 *image.Gray) (*image.Gray, error) {
 //
 //	res, err := blend.AddGrayWeighted(gray1, 0.25, gray2, 0.75)
 func AddGrayWeighted(img1 *image.Gray, w1 float64, img2 *image.Gray, w2 float64) (*image.Gray, error) {
+	return AddGrayWeightedCtx(context.Background(), img1, w1, img2, w2, nil)
+}
+
+// AddGrayWeightedCtx is AddGrayWeighted which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func AddGrayWeightedCtx(ctx context.Context, img1 *image.Gray, w1 float64, img2 *image.Gray,
+	w2 float64, opts *utils.Options) (*image.Gray, error) {
 	size1 := img1.Bounds().Size()
 	size2 := img2.Bounds().Size()
 	if size1.X != size2.X || size1.Y != size2.Y {
@@ -70,12 +99,15 @@ func AddGrayWeighted(img1 *image.Gray, w1 float64, img2 *image.Gray, w2 float64)
 
 	res := image.NewGray(image.Rect(0, 0, size1.X, size1.Y))
 
-	utils.IteratePixels(size1, func(x int, y int) {
+	err := utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size1, func(x int, y int) {
 		p1 := img1.GrayAt(x+o1.X, y+o1.Y)
 		p2 := img2.GrayAt(x+o2.X, y+o2.Y)
 		sum := utils.ClampF64(float64(p1.Y)*w1+float64(p2.Y)*w2, utils.MinUint8, float64(utils.MaxUint8))
 		res.SetGray(x, y, color.Gray{Y: uint8(sum)})
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return res, nil
 }
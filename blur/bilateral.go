@@ -0,0 +1,429 @@
+package blur
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// BilateralGray applies a bilateral filter to a grayscale image. Every pixel is replaced by the weighted average of the
+// pixels in the circular window with the given diameter around it. The weight of a pixel decreases with its distance
+// (controlled by sigmaSpace) and with its intensity difference (controlled by sigmaColor), so smooth areas are
+// blurred while the edges are kept sharp. If diameter is 0 or negative, it is computed from sigmaSpace. The pixels
+// outside of the image are read according to the border.
+// Example of usage:
+//
+//	res, err := blur.BilateralGray(img, 9, 30, 5, padding.BorderReflect)
+//
+// Note: the cost per pixel grows with diameter^2, for large windows see BilateralGridGray.
+func BilateralGray(img *image.Gray, diameter int, sigmaColor float64, sigmaSpace float64,
+	border padding.Border) (*image.Gray, error) {
+	return BilateralGrayCtx(context.Background(), img, diameter, sigmaColor, sigmaSpace, border, nil)
+}
+
+// BilateralGrayCtx is BilateralGray which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func BilateralGrayCtx(ctx context.Context, img *image.Gray, diameter int, sigmaColor float64, sigmaSpace float64,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
+	window, err := newBilateralWindow(diameter, sigmaColor, sigmaSpace)
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := padding.NewGraySamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	colorWeights := make([]float64, int(utils.MaxUint8)+1)
+	for d := range colorWeights {
+		colorWeights[d] = gaussianFunc(float64(d), sigmaColor)
+	}
+	size := img.Bounds().Size()
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		center := int(sampler.GrayAt(x, y).Y)
+		sum, weightSum := 0.0, 0.0
+		for _, o := range window {
+			value := int(sampler.GrayAt(x+o.dx, y+o.dy).Y)
+			diff := value - center
+			if diff < 0 {
+				diff = -diff
+			}
+			weight := o.weight * colorWeights[diff]
+			sum += float64(value) * weight
+			weightSum += weight
+		}
+		res.Pix[y*res.Stride+x] = roundToUint8(sum / weightSum)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BilateralRGBA applies a bilateral filter to an RGBA image. See BilateralGray. The color difference of two pixels is
+// their distance in the CIELAB color space, which follows the perceived difference much better than the distance of
+// the RGB values. The Lab coordinates are scaled by 2.55, so the lightness has the same [0, 255] range as a grayscale
+// image and sigmaColor has the same meaning for both. The alpha channel is kept unchanged.
+// Example of usage:
+//
+//	res, err := blur.BilateralRGBA(img, 9, 30, 5, padding.BorderReflect)
+func BilateralRGBA(img *image.RGBA, diameter int, sigmaColor float64, sigmaSpace float64,
+	border padding.Border) (*image.RGBA, error) {
+	return BilateralRGBACtx(context.Background(), img, diameter, sigmaColor, sigmaSpace, border, nil)
+}
+
+// BilateralRGBACtx is BilateralRGBA which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func BilateralRGBACtx(ctx context.Context, img *image.RGBA, diameter int, sigmaColor float64, sigmaSpace float64,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
+	window, err := newBilateralWindow(diameter, sigmaColor, sigmaSpace)
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := padding.NewRGBASamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	size := img.Bounds().Size()
+	lab := make([][3]float64, size.X*size.Y)
+	utils.IteratePixelsWith(opts.ExecutorOrDefault(), size, func(x, y int) {
+		lab[y*size.X+x] = rgbToLab(sampler.RGBAAt(x, y))
+	})
+	labAt := func(x, y int, pixel color.RGBA) [3]float64 {
+		if x >= 0 && y >= 0 && x < size.X && y < size.Y {
+			return lab[y*size.X+x]
+		}
+		return rgbToLab(pixel)
+	}
+	colorCoeff := -1 / (2 * sigmaColor * sigmaColor)
+	res := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		center := sampler.RGBAAt(x, y)
+		centerLab := lab[y*size.X+x]
+		sumR, sumG, sumB, weightSum := 0.0, 0.0, 0.0, 0.0
+		for _, o := range window {
+			pixel := sampler.RGBAAt(x+o.dx, y+o.dy)
+			pixelLab := labAt(x+o.dx, y+o.dy, pixel)
+			dL := pixelLab[0] - centerLab[0]
+			dA := pixelLab[1] - centerLab[1]
+			dB := pixelLab[2] - centerLab[2]
+			weight := o.weight * math.Exp((dL*dL+dA*dA+dB*dB)*colorCoeff)
+			sumR += float64(pixel.R) * weight
+			sumG += float64(pixel.G) * weight
+			sumB += float64(pixel.B) * weight
+			weightSum += weight
+		}
+		i := y*res.Stride + 4*x
+		res.Pix[i] = roundToUint8(sumR / weightSum)
+		res.Pix[i+1] = roundToUint8(sumG / weightSum)
+		res.Pix[i+2] = roundToUint8(sumB / weightSum)
+		res.Pix[i+3] = center.A
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BilateralGridGray is a fast approximation of BilateralGray using a bilateral grid (Chen, Paris, Durand). The image
+// is downsampled into a 3 dimensional grid (x, y and intensity) with a cell size of sigmaSpace * sigmaSpace *
+// sigmaColor, the grid is blurred and the result is interpolated from it. The cost does not depend on the size of the
+// window, and the larger the sigmas, the smaller and faster the grid is, so it is suited for large sigmas. Both sigmas
+// have to be at least 1 and the grid can have at most MaxBilateralGridCells cells, for smaller sigmas use
+// BilateralGray.
+// Example of usage:
+//
+//	res, err := blur.BilateralGridGray(img, 40, 16)
+func BilateralGridGray(img *image.Gray, sigmaColor float64, sigmaSpace float64) (*image.Gray, error) {
+	return BilateralGridGrayCtx(context.Background(), img, sigmaColor, sigmaSpace, nil)
+}
+
+// BilateralGridGrayCtx is BilateralGridGray which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func BilateralGridGrayCtx(ctx context.Context, img *image.Gray, sigmaColor float64,
+	sigmaSpace float64, opts *utils.Options) (*image.Gray, error) {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	grid, err := newBilateralGrid(size, sigmaColor, sigmaSpace, 1)
+	if err != nil {
+		return nil, err
+	}
+	value := func(x, y int) uint8 {
+		return img.GrayAt(x+offset.X, y+offset.Y).Y
+	}
+	values := make([]float64, 1)
+	err = grid.splat(ctx, func(x, y int) (float64, []float64) {
+		values[0] = float64(value(x, y))
+		return values[0], values
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := grid.blur(ctx); err != nil {
+		return nil, err
+	}
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		var out [1]float64
+		grid.slice(x, y, float64(value(x, y)), out[:])
+		res.Pix[y*res.Stride+x] = roundToUint8(out[0])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BilateralGridRGBA is a fast approximation of BilateralRGBA using a bilateral grid. See BilateralGridGray. To keep the
+// grid 3 dimensional, the color difference is measured by the CIELAB lightness (scaled to [0, 255]) only, the red,
+// green and blue channels are averaged using these weights. The alpha channel is kept unchanged.
+// Example of usage:
+//
+//	res, err := blur.BilateralGridRGBA(img, 40, 16)
+func BilateralGridRGBA(img *image.RGBA, sigmaColor float64, sigmaSpace float64) (*image.RGBA, error) {
+	return BilateralGridRGBACtx(context.Background(), img, sigmaColor, sigmaSpace, nil)
+}
+
+// BilateralGridRGBACtx is BilateralGridRGBA which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func BilateralGridRGBACtx(ctx context.Context, img *image.RGBA, sigmaColor float64,
+	sigmaSpace float64, opts *utils.Options) (*image.RGBA, error) {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	grid, err := newBilateralGrid(size, sigmaColor, sigmaSpace, 3)
+	if err != nil {
+		return nil, err
+	}
+	lightness := make([]float64, size.X*size.Y)
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		lightness[y*size.X+x] = rgbToLab(img.RGBAAt(x+offset.X, y+offset.Y))[0]
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, 3)
+	err = grid.splat(ctx, func(x, y int) (float64, []float64) {
+		pixel := img.RGBAAt(x+offset.X, y+offset.Y)
+		values[0], values[1], values[2] = float64(pixel.R), float64(pixel.G), float64(pixel.B)
+		return lightness[y*size.X+x], values
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := grid.blur(ctx); err != nil {
+		return nil, err
+	}
+	res := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		var out [3]float64
+		grid.slice(x, y, lightness[y*size.X+x], out[:])
+		i := y*res.Stride + 4*x
+		for c := 0; c < 3; c++ {
+			res.Pix[i+c] = roundToUint8(out[c])
+		}
+		res.Pix[i+3] = img.RGBAAt(x+offset.X, y+offset.Y).A
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// bilateralOffset is a position of the bilateral window relative to its center with its spatial weight.
+type bilateralOffset struct {
+	dx     int
+	dy     int
+	weight float64
+}
+
+// newBilateralWindow returns the positions of the circular window with the given diameter. If the diameter is 0 or
+// negative, it is computed from sigmaSpace.
+func newBilateralWindow(diameter int, sigmaColor float64, sigmaSpace float64) ([]bilateralOffset, error) {
+	if sigmaColor <= 0 || sigmaSpace <= 0 {
+		return nil, errors.New("sigma must be bigger then 0")
+	}
+	radius := diameter / 2
+	if diameter <= 0 {
+		radius = int(math.Round(1.5 * sigmaSpace))
+	}
+	var window []bilateralOffset
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			distance := math.Sqrt(float64(dx*dx + dy*dy))
+			if distance > float64(radius) {
+				continue
+			}
+			window = append(window, bilateralOffset{dx: dx, dy: dy, weight: gaussianFunc(distance, sigmaSpace)})
+		}
+	}
+	return window, nil
+}
+
+// srgbToLinear maps an 8 bit sRGB value to linear light.
+var srgbToLinear = func() [256]float64 {
+	var lut [256]float64
+	for i := range lut {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			lut[i] = v / 12.92
+		} else {
+			lut[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return lut
+}()
+
+// rgbToLab converts an sRGB color to CIELAB (D65 white point), with every coordinate scaled by 2.55.
+func rgbToLab(c color.RGBA) [3]float64 {
+	r, g, b := srgbToLinear[c.R], srgbToLinear[c.G], srgbToLinear[c.B]
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return [3]float64{2.55 * (116*fy - 16), 2.55 * 500 * (fx - fy), 2.55 * 200 * (fy - fz)}
+}
+
+func roundToUint8(value float64) uint8 {
+	return uint8(utils.ClampF64(math.Round(value), utils.MinUint8, float64(utils.MaxUint8)))
+}
+
+func labF(t float64) float64 {
+	const delta = 6.0 / 29.0
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29.0
+}
+
+// bilateralGrid holds the downsampled (x, y, range) grid. Every cell stores the sums of the channels followed by the
+// sum of the weights.
+type bilateralGrid struct {
+	size       image.Point
+	sigmaColor float64
+	sigmaSpace float64
+	channels   int
+	width      int
+	height     int
+	depth      int
+	data       []float64
+}
+
+// MaxBilateralGridCells is the maximum number of cells of the grid of BilateralGridGray and BilateralGridRGBA. Every
+// cell takes 8 bytes per channel plus 8 bytes for the weight, and the blur needs a copy of the grid.
+const MaxBilateralGridCells = 1 << 23
+
+// minBilateralGridSigma is the smallest sigma accepted by the bilateral grid, smaller sigmas would make the cells
+// smaller than a pixel or an intensity level.
+const minBilateralGridSigma = 1
+
+// bilateralGridPadding is the number of cells added around the grid in every dimension, so the blur and the
+// interpolation never have to check the bounds.
+const bilateralGridPadding = 1
+
+func newBilateralGrid(size image.Point, sigmaColor float64, sigmaSpace float64, channels int) (*bilateralGrid, error) {
+	if sigmaColor < minBilateralGridSigma || sigmaSpace < minBilateralGridSigma {
+		return nil, errors.New("sigma of the bilateral grid must be at least 1")
+	}
+	g := &bilateralGrid{
+		size:       size,
+		sigmaColor: sigmaColor,
+		sigmaSpace: sigmaSpace,
+		channels:   channels,
+		width:      int(float64(size.X-1)/sigmaSpace) + 2 + 2*bilateralGridPadding,
+		height:     int(float64(size.Y-1)/sigmaSpace) + 2 + 2*bilateralGridPadding,
+		depth:      int(float64(utils.MaxUint8)/sigmaColor) + 2 + 2*bilateralGridPadding,
+	}
+	if g.width*g.height*g.depth > MaxBilateralGridCells {
+		return nil, errors.New("bilateral grid too large, use bigger sigmas or BilateralGray")
+	}
+	g.data = make([]float64, g.width*g.height*g.depth*(channels+1))
+	return g, nil
+}
+
+func (g *bilateralGrid) index(x int, y int, z int) int {
+	return ((z*g.height+y)*g.width + x) * (g.channels + 1)
+}
+
+// splat accumulates every pixel into its nearest cell. at returns the range value of a pixel and its channels.
+func (g *bilateralGrid) splat(ctx context.Context, at func(x, y int) (float64, []float64)) error {
+	for y := 0; y < g.size.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for x := 0; x < g.size.X; x++ {
+			value, channels := at(x, y)
+			gx := int(math.Round(float64(x)/g.sigmaSpace)) + bilateralGridPadding
+			gy := int(math.Round(float64(y)/g.sigmaSpace)) + bilateralGridPadding
+			gz := int(math.Round(value/g.sigmaColor)) + bilateralGridPadding
+			cell := g.data[g.index(gx, gy, gz):]
+			for c, v := range channels {
+				cell[c] += v
+			}
+			cell[g.channels]++
+		}
+	}
+	return nil
+}
+
+// blur convolves the grid with a [1 2 1] kernel along each of its dimensions.
+func (g *bilateralGrid) blur(ctx context.Context) error {
+	stride := g.channels + 1
+	steps := []int{stride, g.width * stride, g.width * g.height * stride}
+	tmp := make([]float64, len(g.data))
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		copy(tmp, g.data)
+		for i := step; i < len(g.data)-step; i++ {
+			g.data[i] = (tmp[i-step] + 2*tmp[i] + tmp[i+step]) / 4
+		}
+	}
+	return nil
+}
+
+// slice interpolates the channels of the grid at the given pixel and range value into out.
+func (g *bilateralGrid) slice(x int, y int, value float64, out []float64) {
+	gx := float64(x)/g.sigmaSpace + bilateralGridPadding
+	gy := float64(y)/g.sigmaSpace + bilateralGridPadding
+	gz := value/g.sigmaColor + bilateralGridPadding
+	x0, y0, z0 := int(gx), int(gy), int(gz)
+	fx, fy, fz := gx-float64(x0), gy-float64(y0), gz-float64(z0)
+	for c := range out {
+		out[c] = 0
+	}
+	weightSum := 0.0
+	for dz := 0; dz <= 1; dz++ {
+		wz := 1 - fz
+		if dz == 1 {
+			wz = fz
+		}
+		for dy := 0; dy <= 1; dy++ {
+			wy := 1 - fy
+			if dy == 1 {
+				wy = fy
+			}
+			for dx := 0; dx <= 1; dx++ {
+				wx := 1 - fx
+				if dx == 1 {
+					wx = fx
+				}
+				w := wx * wy * wz
+				cell := g.data[g.index(x0+dx, y0+dy, z0+dz):]
+				for c := range out {
+					out[c] += w * cell[c]
+				}
+				weightSum += w * cell[g.channels]
+			}
+		}
+	}
+	if weightSum > 0 {
+		for c := range out {
+			out[c] /= weightSum
+		}
+	}
+}
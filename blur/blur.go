@@ -1,12 +1,14 @@
 package blur
 
 import (
+	"context"
 	"errors"
 	"image"
 	"math"
 
 	"github.com/ernyoke/imger/convolution"
 	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
 )
 
 // BoxGray applies average blur to a grayscale image. The amount of bluring effect depends on the kernel size, where
@@ -14,8 +16,14 @@ import (
 // will be updated after the convolution was done for the given area.
 // Border types supported: see convolution package.
 func BoxGray(img *image.Gray, kernelSize image.Point, anchor image.Point, border padding.Border) (*image.Gray, error) {
-	kernel := generateBoxKernel(&kernelSize)
-	return convolution.ConvolveGray(img, kernel.Normalize(), anchor, border)
+	return BoxGrayCtx(context.Background(), img, kernelSize, anchor, border, nil)
+}
+
+// BoxGrayCtx is BoxGray which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func BoxGrayCtx(ctx context.Context, img *image.Gray, kernelSize image.Point, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
+	kernelX, kernelY := generateBoxKernels(kernelSize)
+	return convolution.ConvolveSeparableGrayCtx(ctx, img, kernelX, kernelY, anchor, border, opts)
 }
 
 // BoxRGBA applies average blur to an RGBA image. The amount of bluring effect depends on the kernel size, where
@@ -23,53 +31,82 @@ func BoxGray(img *image.Gray, kernelSize image.Point, anchor image.Point, border
 // will be updated after the convolution was done for the given area.
 // Border types supported: see convolution package.
 func BoxRGBA(img *image.RGBA, kernelSize image.Point, anchor image.Point, border padding.Border) (*image.RGBA, error) {
-	kernel := generateBoxKernel(&kernelSize)
-	return convolution.ConvolveRGBA(img, kernel.Normalize(), anchor, border)
+	return BoxRGBACtx(context.Background(), img, kernelSize, anchor, border, nil)
+}
+
+// BoxRGBACtx is BoxRGBA which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func BoxRGBACtx(ctx context.Context, img *image.RGBA, kernelSize image.Point, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
+	kernelX, kernelY := generateBoxKernels(kernelSize)
+	return convolution.ConvolveSeparableRGBACtx(ctx, img, kernelX, kernelY, anchor, border, opts)
 }
 
 // GaussianBlurGray applies average blur to a grayscale image. The amount of bluring effect depends on the kernel radius
 // and sigma value. The anchor point specifies a point inside the kernel. The pixel value  will be updated after the
 // convolution was done for the given area. For border types see convolution package.
 func GaussianBlurGray(img *image.Gray, radius float64, sigma float64, border padding.Border) (*image.Gray, error) {
+	return GaussianBlurGrayCtx(context.Background(), img, radius, sigma, border, nil)
+}
+
+// GaussianBlurGrayCtx is GaussianBlurGray which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func GaussianBlurGrayCtx(ctx context.Context, img *image.Gray, radius float64, sigma float64,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
 	if radius <= 0 {
 		return nil, errors.New("radius must be bigger then 0")
 	}
-	return convolution.ConvolveGray(img, generateGaussianKernel(radius, sigma).Normalize(), image.Point{X: int(math.Ceil(radius)), Y: int(math.Ceil(radius))}, border)
+	kernel := generateGaussianKernel(radius, sigma)
+	anchor := image.Point{X: int(math.Ceil(radius)), Y: int(math.Ceil(radius))}
+	return convolution.ConvolveSeparableGrayCtx(ctx, img, kernel, kernel, anchor, border, opts)
 }
 
 // GaussianBlurRGBA applies average blur to an RGBA image. The amount of bluring effect depends on the kernel radius
 // and sigma value. The anchor point specifies a point inside the kernel. The pixel value  will be updated after the
 // convolution was done for the given area. For border types see convolution package.
 func GaussianBlurRGBA(img *image.RGBA, radius float64, sigma float64, border padding.Border) (*image.RGBA, error) {
+	return GaussianBlurRGBACtx(context.Background(), img, radius, sigma, border, nil)
+}
+
+// GaussianBlurRGBACtx is GaussianBlurRGBA which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func GaussianBlurRGBACtx(ctx context.Context, img *image.RGBA, radius float64, sigma float64,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
 	if radius <= 0 {
 		return nil, errors.New("radius must be bigger then 0")
 	}
-	return convolution.ConvolveRGBA(img, generateGaussianKernel(radius, sigma).Normalize(), image.Point{X: int(math.Ceil(radius)), Y: int(math.Ceil(radius))}, border)
+	kernel := generateGaussianKernel(radius, sigma)
+	anchor := image.Point{X: int(math.Ceil(radius)), Y: int(math.Ceil(radius))}
+	return convolution.ConvolveSeparableRGBACtx(ctx, img, kernel, kernel, anchor, border, opts)
 }
 
 // -------------------------------------------------------------------------------------------------------
-func generateBoxKernel(kernelSize *image.Point) *convolution.Kernel {
-	kernel, _ := convolution.NewKernel(kernelSize.X, kernelSize.Y)
-	for x := 0; x < kernelSize.X; x++ {
-		for y := 0; y < kernelSize.Y; y++ {
-			kernel.Set(x, y, 1.0/float64(kernelSize.X*kernelSize.Y))
-		}
+// Both the box and the Gaussian kernels are separable, they are generated as their normalized 1D components.
+func generateBoxKernels(kernelSize image.Point) ([]float64, []float64) {
+	kernelX := make([]float64, kernelSize.X)
+	for x := range kernelX {
+		kernelX[x] = 1.0 / float64(kernelSize.X)
 	}
-	return kernel
+	kernelY := make([]float64, kernelSize.Y)
+	for y := range kernelY {
+		kernelY[y] = 1.0 / float64(kernelSize.Y)
+	}
+	return kernelX, kernelY
 }
 
-func generateGaussianKernel(radius float64, sigma float64) *convolution.Kernel {
+func generateGaussianKernel(radius float64, sigma float64) []float64 {
 	length := int(math.Ceil(2*radius + 1))
-	kernel, _ := convolution.NewKernel(length, length)
-	for x := 0; x < length; x++ {
-		for y := 0; y < length; y++ {
-			kernel.Set(x, y, gaussianFunc(float64(x)-radius, float64(y)-radius, sigma))
-		}
+	kernel := make([]float64, length)
+	var sum float64
+	for i := range kernel {
+		kernel[i] = gaussianFunc(float64(i)-radius, sigma)
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
 	}
 	return kernel
 }
 
-func gaussianFunc(x, y, sigma float64) float64 {
-	sigSqr := sigma * sigma
-	return (1.0 / (2 * math.Pi * sigSqr)) * math.Exp(-(x*x+y*y)/(2*sigSqr))
+func gaussianFunc(x, sigma float64) float64 {
+	return math.Exp(-(x * x) / (2 * sigma * sigma))
 }
@@ -1,7 +1,10 @@
 package blur
 
 import (
+	"context"
 	"image"
+	"image/color"
+	"math"
 	"testing"
 
 	"github.com/ernyoke/imger/imgio"
@@ -81,6 +84,258 @@ func TestRGBAGaussianBlurOneRadius(t *testing.T) {
 	utils.CompareRGBAImagesWithOffset(t, expected, actual, 1)
 }
 
+func TestBlurCtxCancelled(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 16, 16))
+	rgba := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	kernelSize := image.Point{X: 3, Y: 3}
+	anchor := image.Point{X: 1, Y: 1}
+	if _, err := BoxGrayCtx(ctx, gray, kernelSize, anchor, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("BoxGrayCtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := BoxRGBACtx(ctx, rgba, kernelSize, anchor, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("BoxRGBACtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := GaussianBlurGrayCtx(ctx, gray, 3, 1, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("GaussianBlurGrayCtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := GaussianBlurRGBACtx(ctx, rgba, 3, 1, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("GaussianBlurRGBACtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := MedianGrayCtx(ctx, gray, 9, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("MedianGrayCtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := MedianRGBACtx(ctx, rgba, 3, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("MedianRGBACtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := BilateralGrayCtx(ctx, gray, 5, 20, 3, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("BilateralGrayCtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+	if _, err := BilateralRGBACtx(ctx, rgba, 5, 20, 3, padding.BorderReflect, nil); err != context.Canceled {
+		t.Errorf("BilateralRGBACtx: expected error %v - actual error %v", context.Canceled, err)
+	}
+}
+
+func Test_MedianGray_SaltAndPepper(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 9, 9))
+	for i := range gray.Pix {
+		gray.Pix[i] = 100
+	}
+	gray.Pix[10] = 255
+	gray.Pix[40] = 0
+	gray.Pix[80] = 255
+	for _, kernelSize := range []int{3, 7} {
+		res, err := MedianGray(gray, kernelSize, padding.BorderReflect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, pix := range res.Pix {
+			if pix != 100 {
+				t.Errorf("Kernel size %d: expected: 100 - actual: %d at %d", kernelSize, pix, i)
+			}
+		}
+	}
+}
+
+func Test_Median_HistogramMatchesSort(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	cropped := gray.SubImage(image.Rect(200, 150, 260, 200)).(*image.Gray)
+	size := cropped.Bounds().Size()
+	for _, border := range []padding.Border{padding.BorderConstant, padding.BorderReplicate, padding.BorderWrap} {
+		sampler, err := padding.NewGraySampler(cropped, border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := func(x, y, _ int) uint8 {
+			return sampler.GrayAt(x, y).Y
+		}
+		ctx := context.Background()
+		for _, radius := range []int{1, 4} {
+			expected := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+			actual := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+			err := medianSort(ctx, utils.Sequential, size, radius, 1, at, func(x, y, _ int, value uint8) {
+				expected.Pix[y*expected.Stride+x] = value
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = medianHistogram(ctx, utils.Sequential, size, radius, 1, at, func(x, y, _ int, value uint8) {
+				actual.Pix[y*actual.Stride+x] = value
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			utils.CompareGrayImages(t, expected, actual)
+		}
+	}
+}
+
+func Test_Median_ParallelMatchesSequential(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	cropped := gray.SubImage(image.Rect(180, 140, 260, 230)).(*image.Gray)
+	rgba := setupTestCaseRGBA(t)
+	croppedRGBA := rgba.SubImage(image.Rect(180, 140, 240, 190)).(*image.RGBA)
+	opts := &utils.Options{Executor: utils.NewParallelExecutor(4)}
+	for _, kernelSize := range []int{3, 9} {
+		expected, err := MedianGray(cropped, kernelSize, padding.BorderReflect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := MedianGrayCtx(context.Background(), cropped, kernelSize, padding.BorderReflect, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareGrayImages(t, expected, actual)
+
+		expectedRGBA, err := MedianRGBA(croppedRGBA, kernelSize, padding.BorderReflect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actualRGBA, err := MedianRGBACtx(context.Background(), croppedRGBA, kernelSize, padding.BorderReflect, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareRGBAImages(t, expectedRGBA, actualRGBA)
+	}
+}
+
+func Test_MedianRGBA_KeepsAlpha(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 5, 5))
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2], rgba.Pix[i+3] = 10, 20, 30, uint8(i)
+	}
+	rgba.Pix[48] = 255
+	res, err := MedianRGBA(rgba, 3, padding.BorderReplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(res.Pix); i += 4 {
+		if res.Pix[i] != 10 || res.Pix[i+1] != 20 || res.Pix[i+2] != 30 || res.Pix[i+3] != uint8(i) {
+			t.Errorf("Expected: [10 20 30 %d] - actual: %v at %d", uint8(i), res.Pix[i:i+4], i/4)
+		}
+	}
+}
+
+func Test_MedianGray_InvalidKernelSize(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	for _, kernelSize := range []int{0, 4, -3} {
+		if _, err := MedianGray(gray, kernelSize, padding.BorderReflect); err == nil {
+			t.Errorf("Expected an error for kernel size %d", kernelSize)
+		}
+	}
+}
+
+// stepEdgeGray returns an image with a vertical edge between the values 50 and 200 and a small noise on both sides.
+func stepEdgeGray() *image.Gray {
+	gray := image.NewGray(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			value := 50
+			if x >= 10 {
+				value = 200
+			}
+			gray.Pix[y*gray.Stride+x] = uint8(value + (x*7+y*3)%5 - 2)
+		}
+	}
+	return gray
+}
+
+func Test_BilateralGray_KeepsEdges(t *testing.T) {
+	gray := stepEdgeGray()
+	res, err := BilateralGray(gray, 7, 20, 3, padding.BorderReflect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			expected := 50.0
+			if x >= 10 {
+				expected = 200
+			}
+			if actual := float64(res.GrayAt(x, y).Y); actual < expected-2 || actual > expected+2 {
+				t.Errorf("Expected: %f +- 2 - actual: %f at %d, %d", expected, actual, x, y)
+			}
+		}
+	}
+}
+
+func Test_BilateralGridGray_ApproximatesBilateralGray(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	cropped := gray.SubImage(image.Rect(100, 100, 200, 200)).(*image.Gray)
+	sigmaColor, sigmaSpace := 30.0, 6.0
+	expected, err := BilateralGray(cropped, 0, sigmaColor, sigmaSpace, padding.BorderReflect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := BilateralGridGray(cropped, sigmaColor, sigmaSpace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := 0.0
+	for i := range expected.Pix {
+		diff += math.Abs(float64(expected.Pix[i]) - float64(actual.Pix[i]))
+	}
+	if mean := diff / float64(len(expected.Pix)); mean > 6 {
+		t.Errorf("Expected a mean difference below 6 - actual: %f", mean)
+	}
+}
+
+func Test_BilateralRGBA_KeepsEdgesAndAlpha(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 12, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 12; x++ {
+			c := color.RGBA{R: 200, G: 30, B: 30, A: 255}
+			if x >= 6 {
+				c = color.RGBA{R: 30, G: 30, B: 200, A: 128}
+			}
+			rgba.SetRGBA(x, y, c)
+		}
+	}
+	for _, filter := range []func(*image.RGBA) (*image.RGBA, error){
+		func(img *image.RGBA) (*image.RGBA, error) {
+			return BilateralRGBA(img, 5, 10, 2, padding.BorderReplicate)
+		},
+		func(img *image.RGBA) (*image.RGBA, error) {
+			return BilateralGridRGBA(img, 10, 2)
+		},
+	} {
+		res, err := filter(rgba)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareRGBAImagesWithOffset(t, rgba, res, 1)
+	}
+}
+
+func Test_Bilateral_InvalidSigma(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	if _, err := BilateralGray(gray, 5, 0, 3, padding.BorderReflect); err == nil {
+		t.Error("Expected an error for sigmaColor 0")
+	}
+	if _, err := BilateralGridGray(gray, 10, -1); err == nil {
+		t.Error("Expected an error for a negative sigmaSpace")
+	}
+	if _, err := BilateralGridGray(gray, 0.001, 4); err == nil {
+		t.Error("Expected an error for a sigmaColor below 1")
+	}
+	large := image.NewGray(image.Rect(0, 0, 4000, 4000))
+	if _, err := BilateralGridGray(large, 1, 1); err == nil {
+		t.Error("Expected an error for a grid larger than MaxBilateralGridCells")
+	}
+}
+
+func Test_BilateralGridCtx_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BilateralGridGrayCtx(ctx, image.NewGray(image.Rect(0, 0, 8, 8)), 10, 2, nil); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+	if _, err := BilateralGridRGBACtx(ctx, image.NewRGBA(image.Rect(0, 0, 8, 8)), 10, 2, nil); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+}
+
 // ---------------------------------------------------------------------------------
 
 // -----------------------------Acceptance tests------------------------------------
@@ -162,3 +417,27 @@ func Test_Acceptance_RGBAGaussianBlurInt_Cropped(t *testing.T) {
 }
 
 // ----------------------------------------------------------------------------------
+
+func Test_Acceptance_GrayMedian(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	blurred, _ := MedianGray(gray, 9, padding.BorderReflect)
+	tearDownTestCase(t, blurred, "../res/blur/grayMedian.jpg")
+}
+
+func Test_Acceptance_RGBAMedian(t *testing.T) {
+	rgba := setupTestCaseRGBA(t)
+	blurred, _ := MedianRGBA(rgba, 9, padding.BorderReflect)
+	tearDownTestCase(t, blurred, "../res/blur/rgbaMedian.jpg")
+}
+
+func Test_Acceptance_RGBABilateral(t *testing.T) {
+	rgba := setupTestCaseRGBA(t)
+	blurred, _ := BilateralRGBA(rgba, 9, 25, 5, padding.BorderReflect)
+	tearDownTestCase(t, blurred, "../res/blur/rgbaBilateral.jpg")
+}
+
+func Test_Acceptance_RGBABilateralGrid(t *testing.T) {
+	rgba := setupTestCaseRGBA(t)
+	blurred, _ := BilateralGridRGBA(rgba, 25, 8)
+	tearDownTestCase(t, blurred, "../res/blur/rgbaBilateralGrid.jpg")
+}
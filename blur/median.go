@@ -0,0 +1,219 @@
+package blur
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// medianSortMaxSize is the largest window size for which the median is found by sorting the window. Larger windows use
+// the constant-time histogram algorithm.
+const medianSortMaxSize = 5
+
+// MedianGray applies median blur to a grayscale image: every pixel is replaced by the median of the kernelSize *
+// kernelSize window centered on it. The median removes salt-and-pepper noise while keeping the edges sharp. The
+// kernel size has to be a positive odd number. The pixels outside of the image are read according to the border.
+// Large windows are processed in constant time per pixel using the histogram based algorithm of Perreault and Hebert.
+// Example of usage:
+//
+//	res, err := blur.MedianGray(img, 5, padding.BorderReflect)
+func MedianGray(img *image.Gray, kernelSize int, border padding.Border) (*image.Gray, error) {
+	return MedianGrayCtx(context.Background(), img, kernelSize, border, nil)
+}
+
+// MedianGrayCtx is MedianGray which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func MedianGrayCtx(ctx context.Context, img *image.Gray, kernelSize int, border padding.Border,
+	opts *utils.Options) (*image.Gray, error) {
+	if kernelSize < 1 || kernelSize%2 == 0 {
+		return nil, errors.New("kernel size must be a positive odd number")
+	}
+	sampler, err := padding.NewGraySamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	size := img.Bounds().Size()
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	at := func(x, y, _ int) uint8 {
+		return sampler.GrayAt(x, y).Y
+	}
+	set := func(x, y, _ int, value uint8) {
+		res.Pix[y*res.Stride+x] = value
+	}
+	if err := median(ctx, opts.ExecutorOrDefault(), size, kernelSize/2, 1, at, set); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MedianRGBA applies median blur to an RGBA image. The median is computed separately for the red, green and blue
+// channels, the alpha channel is kept unchanged. See MedianGray.
+// Example of usage:
+//
+//	res, err := blur.MedianRGBA(img, 5, padding.BorderReflect)
+func MedianRGBA(img *image.RGBA, kernelSize int, border padding.Border) (*image.RGBA, error) {
+	return MedianRGBACtx(context.Background(), img, kernelSize, border, nil)
+}
+
+// MedianRGBACtx is MedianRGBA which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func MedianRGBACtx(ctx context.Context, img *image.RGBA, kernelSize int, border padding.Border,
+	opts *utils.Options) (*image.RGBA, error) {
+	if kernelSize < 1 || kernelSize%2 == 0 {
+		return nil, errors.New("kernel size must be a positive odd number")
+	}
+	sampler, err := padding.NewRGBASamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	res := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	at := func(x, y, c int) uint8 {
+		pixel := sampler.RGBAAt(x, y)
+		switch c {
+		case 0:
+			return pixel.R
+		case 1:
+			return pixel.G
+		}
+		return pixel.B
+	}
+	set := func(x, y, c int, value uint8) {
+		res.Pix[y*res.Stride+4*x+c] = value
+	}
+	if err := median(ctx, opts.ExecutorOrDefault(), size, kernelSize/2, 3, at, set); err != nil {
+		return nil, err
+	}
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		res.Pix[y*res.Stride+4*x+3] = img.RGBAAt(x+offset.X, y+offset.Y).A
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// median computes the median of the (2*radius+1) * (2*radius+1) window around every position of an area with the
+// given size for every channel. at reads a value, positions outside of the area included; set writes the result. The
+// rows are divided into bands by the executor e.
+func median(ctx context.Context, e utils.Executor, size image.Point, radius int, channels int,
+	at func(x, y, c int) uint8, set func(x, y, c int, value uint8)) error {
+	if 2*radius+1 <= medianSortMaxSize {
+		return medianSort(ctx, e, size, radius, channels, at, set)
+	}
+	return medianHistogram(ctx, e, size, radius, channels, at, set)
+}
+
+// medianSort collects every window and selects its middle element.
+func medianSort(ctx context.Context, e utils.Executor, size image.Point, radius int, channels int,
+	at func(x, y, c int) uint8, set func(x, y, c int, value uint8)) error {
+	if size.X <= 0 {
+		return ctx.Err()
+	}
+	e.IterateRows(size.Y, func(startY, endY int) {
+		window := make([]uint8, (2*radius+1)*(2*radius+1))
+		for y := startY; y < endY; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for x := 0; x < size.X; x++ {
+				for c := 0; c < channels; c++ {
+					i := 0
+					for wy := y - radius; wy <= y+radius; wy++ {
+						for wx := x - radius; wx <= x+radius; wx++ {
+							window[i] = at(wx, wy, c)
+							i++
+						}
+					}
+					// insertion sort, the windows are small
+					for j := 1; j < len(window); j++ {
+						for k := j; k > 0 && window[k-1] > window[k]; k-- {
+							window[k-1], window[k] = window[k], window[k-1]
+						}
+					}
+					set(x, y, c, window[len(window)/2])
+				}
+			}
+		}
+	})
+	return ctx.Err()
+}
+
+// medianHistogram implements "Median Filtering in Constant Time" (Perreault, Hebert). A histogram is kept for every
+// column of the window height, which is moved one row down for every row of the result. The histogram of the window
+// is moved to the right by adding the histogram of the entering column and subtracting the one of the leaving column,
+// so the cost per pixel does not depend on the radius. Every band of rows keeps its own column histograms, starting
+// at the first row of the band.
+func medianHistogram(ctx context.Context, e utils.Executor, size image.Point, radius int, channels int,
+	at func(x, y, c int) uint8, set func(x, y, c int, value uint8)) error {
+	if size.X <= 0 {
+		return ctx.Err()
+	}
+	const bins = 256
+	width := size.X + 2*radius
+	half := int32((2*radius+1)*(2*radius+1)) / 2
+	e.IterateRows(size.Y, func(startY, endY int) {
+		// the histogram of column cx (image column cx-radius) for channel c starts at (c*width+cx)*bins
+		columns := make([]int32, channels*width*bins)
+		for c := 0; c < channels; c++ {
+			for cx := 0; cx < width; cx++ {
+				column := columns[(c*width+cx)*bins:]
+				for y := startY - radius; y <= startY+radius; y++ {
+					column[at(cx-radius, y, c)]++
+				}
+			}
+		}
+		window := make([]int32, bins)
+		for y := startY; y < endY; y++ {
+			if ctx.Err() != nil {
+				return
+			}
+			for c := 0; c < channels; c++ {
+				if y > startY {
+					for cx := 0; cx < width; cx++ {
+						column := columns[(c*width+cx)*bins:]
+						column[at(cx-radius, y-radius-1, c)]--
+						column[at(cx-radius, y+radius, c)]++
+					}
+				}
+				for i := range window {
+					window[i] = 0
+				}
+				for cx := 0; cx < 2*radius+1; cx++ {
+					addHistogram(window, columns[(c*width+cx)*bins:(c*width+cx+1)*bins], 1)
+				}
+				for x := 0; x < size.X; x++ {
+					if x > 0 {
+						entering := c*width + x + 2*radius
+						leaving := c*width + x - 1
+						addHistogram(window, columns[entering*bins:(entering+1)*bins], 1)
+						addHistogram(window, columns[leaving*bins:(leaving+1)*bins], -1)
+					}
+					set(x, y, c, histogramMedian(window, half))
+				}
+			}
+		}
+	})
+	return ctx.Err()
+}
+
+func addHistogram(dst []int32, src []int32, sign int32) {
+	for i, v := range src {
+		dst[i] += sign * v
+	}
+}
+
+// histogramMedian returns the value whose cumulative count first exceeds half.
+func histogramMedian(histogram []int32, half int32) uint8 {
+	var sum int32
+	for v, count := range histogram {
+		sum += count
+		if sum > half {
+			return uint8(v)
+		}
+	}
+	return uint8(len(histogram) - 1)
+}
@@ -0,0 +1,222 @@
+// Package components implements the connected-component labeling of binary images and the measurement of the
+// labeled regions.
+package components
+
+import (
+	"errors"
+	"image"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// Connectivity is an enum type for the neighbourhoods which define whether two pixels are connected
+type Connectivity int
+
+const (
+	// Connectivity4 - pixels are connected if they share an edge (left, right, up, down)
+	Connectivity4 Connectivity = iota
+	// Connectivity8 - pixels are connected if they share an edge or a corner
+	Connectivity8
+)
+
+// Labels is a label image: every pixel holds the label of the component it belongs to. The background has the label 0,
+// the components are labeled from 1 to Count in the order they are first reached scanning the rows from the top.
+// The labels are indexed as Pix[y*Width+x].
+type Labels struct {
+	Pix    []int32
+	Width  int
+	Height int
+	Count  int
+}
+
+// Component holds the measurements of a labeled region.
+type Component struct {
+	// Label is the label of the region in the label image
+	Label int32
+	// Area is the number of pixels of the region
+	Area int
+	// Bounds is the smallest rectangle containing the region
+	Bounds image.Rectangle
+	// CentroidX and CentroidY is the mean position of the pixels of the region
+	CentroidX float64
+	CentroidY float64
+	// Perimeter is the number of pixel edges between the region and the rest of the image (the background, the other
+	// regions or the outside of the image), the edges of the holes included
+	Perimeter int
+}
+
+// Label labels the connected components of a binary image, in which every non-zero pixel is foreground. Returns the
+// label image, which has the size of the input image.
+// Example of usage:
+//
+//	binary, _ := threshold.OtsuThreshold(img, threshold.ThreshBinary)
+//	labels, err := components.Label(binary, components.Connectivity8)
+//	fmt.Println("number of blobs:", labels.Count)
+func Label(img *image.Gray, connectivity Connectivity) (*Labels, error) {
+	var neighbours []image.Point
+	switch connectivity {
+	case Connectivity4:
+		neighbours = []image.Point{{X: -1, Y: 0}, {X: 0, Y: -1}}
+	case Connectivity8:
+		neighbours = []image.Point{{X: -1, Y: 0}, {X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1}}
+	default:
+		return nil, errors.New("invalid connectivity")
+	}
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	labels := &Labels{Pix: make([]int32, size.X*size.Y), Width: size.X, Height: size.Y}
+
+	// first pass: provisional labels, the equivalent ones are merged in a union-find forest
+	parent := []int32{0}
+	find := func(l int32) int32 {
+		for parent[l] != l {
+			parent[l] = parent[parent[l]]
+			l = parent[l]
+		}
+		return l
+	}
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if img.Pix[img.PixOffset(x+offset.X, y+offset.Y)] == 0 {
+				continue
+			}
+			var label int32
+			for _, n := range neighbours {
+				nx, ny := x+n.X, y+n.Y
+				if nx < 0 || ny < 0 || nx >= size.X {
+					continue
+				}
+				neighbour := labels.Pix[ny*size.X+nx]
+				if neighbour == 0 {
+					continue
+				}
+				if label == 0 {
+					label = find(neighbour)
+					continue
+				}
+				// merge the two trees, the smaller root becomes the parent
+				a, b := find(label), find(neighbour)
+				if a < b {
+					parent[b] = a
+					label = a
+				} else if b < a {
+					parent[a] = b
+					label = b
+				}
+			}
+			if label == 0 {
+				label = int32(len(parent))
+				parent = append(parent, label)
+			}
+			labels.Pix[y*size.X+x] = label
+		}
+	}
+
+	// second pass: the provisional labels are replaced by consecutive final labels
+	final := make([]int32, len(parent))
+	var count int32
+	for l := 1; l < len(parent); l++ {
+		root := find(int32(l))
+		if final[root] == 0 {
+			count++
+			final[root] = count
+		}
+		final[l] = final[root]
+	}
+	for i, l := range labels.Pix {
+		labels.Pix[i] = final[l]
+	}
+	labels.Count = int(count)
+	return labels, nil
+}
+
+// At returns the label of the pixel at {x, y}.
+func (l *Labels) At(x, y int) int32 {
+	return l.Pix[y*l.Width+x]
+}
+
+// Stats measures every component of the label image. The returned slice is indexed by label-1.
+// Example of usage:
+//
+//	for _, c := range labels.Stats() {
+//		fmt.Println(c.Label, c.Area, c.Bounds, c.CentroidX, c.CentroidY, c.Perimeter)
+//	}
+func (l *Labels) Stats() []Component {
+	stats := make([]Component, l.Count)
+	sumX := make([]int, l.Count)
+	sumY := make([]int, l.Count)
+	for i := range stats {
+		stats[i].Label = int32(i + 1)
+	}
+	at := func(x, y int) int32 {
+		if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+			return 0
+		}
+		return l.Pix[y*l.Width+x]
+	}
+	for y := 0; y < l.Height; y++ {
+		for x := 0; x < l.Width; x++ {
+			label := l.Pix[y*l.Width+x]
+			if label == 0 {
+				continue
+			}
+			c := &stats[label-1]
+			pixel := image.Rect(x, y, x+1, y+1)
+			if c.Area == 0 {
+				c.Bounds = pixel
+			} else {
+				c.Bounds = c.Bounds.Union(pixel)
+			}
+			c.Area++
+			sumX[label-1] += x
+			sumY[label-1] += y
+			for _, n := range []image.Point{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 0, Y: 1}} {
+				if at(x+n.X, y+n.Y) != label {
+					c.Perimeter++
+				}
+			}
+		}
+	}
+	for i := range stats {
+		stats[i].CentroidX = float64(sumX[i]) / float64(stats[i].Area)
+		stats[i].CentroidY = float64(sumY[i]) / float64(stats[i].Area)
+	}
+	return stats
+}
+
+// Mask returns a binary image in which the pixels of the components for which keep returns true are white.
+// Example of usage:
+//
+//	mask := labels.Mask(func(c components.Component) bool { return c.Bounds.Dx() > c.Bounds.Dy() })
+func (l *Labels) Mask(keep func(c Component) bool) *image.Gray {
+	stats := l.Stats()
+	kept := make([]bool, l.Count+1)
+	for _, c := range stats {
+		kept[c.Label] = keep(c)
+	}
+	res := image.NewGray(image.Rect(0, 0, l.Width, l.Height))
+	for i, label := range l.Pix {
+		if kept[label] {
+			res.Pix[i] = utils.MaxUint8
+		}
+	}
+	return res
+}
+
+// FilterByArea removes the components of a binary image whose area is smaller than minArea or bigger than maxArea. If
+// maxArea is 0, there is no upper limit. The kept components are white in the returned mask.
+// Example of usage:
+//
+//	cleaned, err := components.FilterByArea(binary, components.Connectivity8, 20, 0)
+func FilterByArea(img *image.Gray, connectivity Connectivity, minArea int, maxArea int) (*image.Gray, error) {
+	if minArea < 0 || maxArea < 0 || (maxArea > 0 && minArea > maxArea) {
+		return nil, errors.New("invalid area range")
+	}
+	labels, err := Label(img, connectivity)
+	if err != nil {
+		return nil, err
+	}
+	return labels.Mask(func(c Component) bool {
+		return c.Area >= minArea && (maxArea == 0 || c.Area <= maxArea)
+	}), nil
+}
@@ -0,0 +1,189 @@
+package components
+
+import (
+	"image"
+	"testing"
+
+	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/threshold"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+// binaryFromRows creates a binary image in which '#' is white and everything else is black.
+func binaryFromRows(rows []string) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+	return img
+}
+
+func compareLabels(t *testing.T, expected []int32, actual *Labels) {
+	for i := range expected {
+		if expected[i] != actual.Pix[i] {
+			t.Fatalf("Expected labels %v - actual labels %v", expected, actual.Pix)
+		}
+	}
+}
+
+var diagonal = []string{
+	"##..#",
+	"##.#.",
+	"..#..",
+	"#....",
+}
+
+func Test_Label_Connectivity4(t *testing.T) {
+	labels, err := Label(binaryFromRows(diagonal), Connectivity4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if labels.Count != 5 {
+		t.Errorf("Expected 5 components - actual %d", labels.Count)
+	}
+	compareLabels(t, []int32{
+		1, 1, 0, 0, 2,
+		1, 1, 0, 3, 0,
+		0, 0, 4, 0, 0,
+		5, 0, 0, 0, 0,
+	}, labels)
+}
+
+func Test_Label_Connectivity8(t *testing.T) {
+	labels, err := Label(binaryFromRows(diagonal), Connectivity8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if labels.Count != 2 {
+		t.Errorf("Expected 2 components - actual %d", labels.Count)
+	}
+	compareLabels(t, []int32{
+		1, 1, 0, 0, 1,
+		1, 1, 0, 1, 0,
+		0, 0, 1, 0, 0,
+		2, 0, 0, 0, 0,
+	}, labels)
+}
+
+func Test_Label_MergesEquivalentLabels(t *testing.T) {
+	// a "U" shape gets two provisional labels which meet on the last row
+	labels, err := Label(binaryFromRows([]string{
+		"#.#.#",
+		"#.#.#",
+		"#####",
+	}), Connectivity4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if labels.Count != 1 {
+		t.Errorf("Expected 1 component - actual %d", labels.Count)
+	}
+}
+
+func Test_Label_Cropped(t *testing.T) {
+	img := binaryFromRows([]string{
+		"#....",
+		".#.##",
+		".#...",
+	})
+	labels, err := Label(img.SubImage(image.Rect(1, 1, 5, 3)).(*image.Gray), Connectivity8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if labels.Width != 4 || labels.Height != 2 || labels.Count != 2 {
+		t.Fatalf("Expected 4x2 labels with 2 components - actual %dx%d with %d", labels.Width, labels.Height,
+			labels.Count)
+	}
+	compareLabels(t, []int32{1, 0, 2, 2, 1, 0, 0, 0}, labels)
+}
+
+func Test_Label_InvalidConnectivity(t *testing.T) {
+	if _, err := Label(binaryFromRows(diagonal), Connectivity(42)); err == nil {
+		t.Error("Expected error for invalid connectivity")
+	}
+}
+
+func Test_Labels_Stats(t *testing.T) {
+	labels, _ := Label(binaryFromRows([]string{
+		"......",
+		".###..",
+		".#.#..",
+		".###.#",
+	}), Connectivity4)
+	stats := labels.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 components - actual %d", len(stats))
+	}
+	ring := stats[0]
+	if ring.Label != 1 || ring.Area != 8 || ring.Bounds != image.Rect(1, 1, 4, 4) {
+		t.Errorf("Unexpected ring stats: %+v", ring)
+	}
+	if !utils.IsEqualFloat64(ring.CentroidX, 2) || !utils.IsEqualFloat64(ring.CentroidY, 2) {
+		t.Errorf("Expected ring centroid (2, 2) - actual (%f, %f)", ring.CentroidX, ring.CentroidY)
+	}
+	// 12 outer edges and 4 edges of the hole
+	if ring.Perimeter != 16 {
+		t.Errorf("Expected ring perimeter 16 - actual %d", ring.Perimeter)
+	}
+	dot := stats[1]
+	if dot.Label != 2 || dot.Area != 1 || dot.Bounds != image.Rect(5, 3, 6, 4) || dot.Perimeter != 4 ||
+		!utils.IsEqualFloat64(dot.CentroidX, 5) || !utils.IsEqualFloat64(dot.CentroidY, 3) {
+		t.Errorf("Unexpected dot stats: %+v", dot)
+	}
+}
+
+func Test_FilterByArea(t *testing.T) {
+	img := binaryFromRows([]string{
+		"#....###",
+		"....####",
+		"##......",
+		"##.....#",
+	})
+	cleaned, err := FilterByArea(img, Connectivity8, 2, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	utils.CompareGrayImages(t, binaryFromRows([]string{
+		"........",
+		"........",
+		"##......",
+		"##......",
+	}), cleaned)
+	cleaned, err = FilterByArea(img, Connectivity8, 2, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	utils.CompareGrayImages(t, binaryFromRows([]string{
+		".....###",
+		"....####",
+		"##......",
+		"##......",
+	}), cleaned)
+	if _, err := FilterByArea(img, Connectivity8, 5, 2); err == nil {
+		t.Error("Expected error for invalid area range")
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+func Test_Acceptance_FilterByArea(t *testing.T) {
+	path := "../res/building.jpg"
+	img, err := imgio.ImreadGray(path)
+	if err != nil {
+		t.Fatalf("Could not read image from path: %s", path)
+	}
+	binary, _ := threshold.OtsuThreshold(img, threshold.ThreshBinary)
+	cleaned, err := FilterByArea(binary, Connectivity8, 50, 0)
+	if err != nil {
+		t.Fatalf("Should not reach this point!")
+	}
+	path = "../res/components/filterByArea.png"
+	if err := imgio.Imwrite(cleaned, path); err != nil {
+		t.Errorf("Could not write image to path: %s", path)
+	}
+}
@@ -0,0 +1,228 @@
+// Package contours implements the tracing of the borders of binary images and the measurement and simplification of
+// the traced polygons.
+package contours
+
+import (
+	"errors"
+	"image"
+)
+
+// Mode is an enum type for selecting which contours are returned and how they are related
+type Mode int
+
+const (
+	// ModeExternal - only the outer borders of the outermost components, without hierarchy
+	ModeExternal Mode = iota
+	// ModeList - every outer and hole border, without hierarchy
+	ModeList
+	// ModeTree - every outer and hole border with the full hierarchy: the parent of a hole is the outer border of the
+	// component containing it, the parent of an outer border is the hole it is in
+	ModeTree
+)
+
+// Contour is a border traced on a binary image.
+type Contour struct {
+	// Points are the positions of the border pixels in the order they were traced, without repeating the first one
+	Points []image.Point
+	// Hole is true for the borders between a component and a hole inside of it
+	Hole bool
+	// Parent is the index of the enclosing contour in the returned slice, -1 if there is none
+	Parent int
+}
+
+// FindContours traces the borders of the components of a binary image, in which every non-zero pixel is foreground
+// and the components are 8-connected (the holes 4-connected), using the border following algorithm of Suzuki and
+// Abe. The positions are relative to the top-left corner of the image. The contours are returned in the order their
+// first pixel is reached scanning the rows from the top, so a parent always precedes its children.
+// Example of usage:
+//
+//	edges, _ := edgedetection.CannyGray(img, 50, 150, 2)
+//	found, err := contours.FindContours(edges, contours.ModeExternal)
+//	for _, c := range found {
+//		polygon := contours.ApproxPolyDP(c.Points, 0.02*contours.ArcLength(c.Points, true), true)
+//	}
+func FindContours(binary *image.Gray, mode Mode) ([]Contour, error) {
+	if mode != ModeExternal && mode != ModeList && mode != ModeTree {
+		return nil, errors.New("invalid contour retrieval mode")
+	}
+	all := traceBorders(binary)
+	switch mode {
+	case ModeExternal:
+		var res []Contour
+		for _, c := range all {
+			if !c.Hole && c.Parent == -1 {
+				res = append(res, c)
+			}
+		}
+		return res, nil
+	case ModeList:
+		for i := range all {
+			all[i].Parent = -1
+		}
+	}
+	return all, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// directions lists the 8 neighbours in counterclockwise order (as displayed, with the y axis pointing downwards),
+// starting from the right neighbour.
+var directions = [8]image.Point{
+	{X: 1, Y: 0}, {X: 1, Y: -1}, {X: 0, Y: -1}, {X: -1, Y: -1},
+	{X: -1, Y: 0}, {X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1},
+}
+
+func direction(from image.Point, to image.Point) int {
+	d := to.Sub(from)
+	for i, dir := range directions {
+		if dir == d {
+			return i
+		}
+	}
+	return -1
+}
+
+// borderTracer holds the state of the Suzuki-Abe algorithm. The image is copied into a grid with a one pixel wide
+// background frame, in which the foreground pixels are 1 and the traced border pixels get the number of their border
+// (negative if the pixel right of them is background).
+type borderTracer struct {
+	grid   []int32
+	stride int
+}
+
+func (t *borderTracer) at(p image.Point) int32 {
+	return t.grid[(p.Y+1)*t.stride+p.X+1]
+}
+
+func (t *borderTracer) set(p image.Point, value int32) {
+	t.grid[(p.Y+1)*t.stride+p.X+1] = value
+}
+
+func traceBorders(binary *image.Gray) []Contour {
+	size := binary.Bounds().Size()
+	offset := binary.Bounds().Min
+	t := &borderTracer{grid: make([]int32, (size.X+2)*(size.Y+2)), stride: size.X + 2}
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if binary.Pix[binary.PixOffset(x+offset.X, y+offset.Y)] != 0 {
+				t.set(image.Point{X: x, Y: y}, 1)
+			}
+		}
+	}
+
+	// the border numbered n is contours[n-2], the border 1 is the frame of the image
+	var contours []Contour
+	isHole := func(n int32) bool {
+		return n == 1 || contours[n-2].Hole
+	}
+	parent := func(n int32) int {
+		if n == 1 {
+			return -1
+		}
+		return contours[n-2].Parent
+	}
+	nbd := int32(1)
+	for y := 0; y < size.Y; y++ {
+		lnbd := int32(1)
+		for x := 0; x < size.X; x++ {
+			p := image.Point{X: x, Y: y}
+			value := t.at(p)
+			if value == 0 {
+				continue
+			}
+			var from image.Point
+			hole := false
+			if value == 1 && t.at(image.Point{X: x - 1, Y: y}) == 0 {
+				from = image.Point{X: x - 1, Y: y}
+			} else if value >= 1 && t.at(image.Point{X: x + 1, Y: y}) == 0 {
+				from = image.Point{X: x + 1, Y: y}
+				hole = true
+				if value > 1 {
+					lnbd = value
+				}
+			} else {
+				if value != 1 {
+					lnbd = abs(value)
+				}
+				continue
+			}
+
+			nbd++
+			// the parent is decided by the type of the last border met on the row
+			c := Contour{Hole: hole}
+			if hole == isHole(lnbd) {
+				c.Parent = parent(lnbd)
+			} else if lnbd == 1 {
+				c.Parent = -1
+			} else {
+				c.Parent = int(lnbd - 2)
+			}
+			c.Points = t.follow(p, from, nbd)
+			contours = append(contours, c)
+
+			if value := t.at(p); value != 1 {
+				lnbd = abs(value)
+			}
+		}
+	}
+	return contours
+}
+
+// follow traces the border starting at the pixel start, the background neighbour from which it was found, and marks
+// its pixels with the number nbd. Returns the traced positions.
+func (t *borderTracer) follow(start image.Point, from image.Point, nbd int32) []image.Point {
+	// find the first foreground neighbour clockwise from the background neighbour
+	d0 := direction(start, from)
+	first := image.Point{}
+	found := false
+	for i := 0; i < 8; i++ {
+		q := start.Add(directions[(d0-i+8)%8])
+		if t.at(q) != 0 {
+			first, found = q, true
+			break
+		}
+	}
+	if !found {
+		// isolated pixel
+		t.set(start, -nbd)
+		return []image.Point{start}
+	}
+
+	points := []image.Point{start}
+	previous, current := first, start
+	for {
+		// search counterclockwise from the neighbour after the previous pixel
+		dp := direction(current, previous)
+		var next image.Point
+		rightIsBackground := false
+		for i := 1; i <= 8; i++ {
+			d := (dp + i) % 8
+			q := current.Add(directions[d])
+			if t.at(q) != 0 {
+				next = q
+				break
+			}
+			if d == 0 {
+				rightIsBackground = true
+			}
+		}
+		if rightIsBackground {
+			t.set(current, -nbd)
+		} else if t.at(current) == 1 {
+			t.set(current, nbd)
+		}
+		// the first pixel found clockwise is the last one of the border
+		if next == start && current == first {
+			break
+		}
+		previous, current = current, next
+		points = append(points, current)
+	}
+	return points
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
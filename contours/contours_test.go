@@ -0,0 +1,160 @@
+package contours
+
+import (
+	"image"
+	"testing"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+// binaryFromRows creates a binary image in which '#' is white and everything else is black.
+func binaryFromRows(rows []string) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+	return img
+}
+
+func comparePoints(t *testing.T, expected []image.Point, actual []image.Point) {
+	if len(expected) != len(actual) {
+		t.Fatalf("Expected points %v - actual points %v", expected, actual)
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Fatalf("Expected points %v - actual points %v", expected, actual)
+		}
+	}
+}
+
+func Test_FindContours_Square(t *testing.T) {
+	found, err := FindContours(binaryFromRows([]string{
+		".....",
+		".###.",
+		".###.",
+		".###.",
+		".....",
+	}), ModeTree)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(found) != 1 || found[0].Hole || found[0].Parent != -1 {
+		t.Fatalf("Expected a single outer contour - actual %+v", found)
+	}
+	comparePoints(t, []image.Point{
+		{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 1, Y: 3}, {X: 2, Y: 3},
+		{X: 3, Y: 3}, {X: 3, Y: 2}, {X: 3, Y: 1}, {X: 2, Y: 1},
+	}, found[0].Points)
+}
+
+func Test_FindContours_ThinShapes(t *testing.T) {
+	found, err := FindContours(binaryFromRows([]string{
+		"#....",
+		".....",
+		"..###",
+		".....",
+		"#....",
+		".#...",
+	}), ModeTree)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(found) != 3 {
+		t.Fatalf("Expected 3 contours - actual %+v", found)
+	}
+	comparePoints(t, []image.Point{{X: 0, Y: 0}}, found[0].Points)
+	// a line is traced forward and back
+	comparePoints(t, []image.Point{{X: 2, Y: 2}, {X: 3, Y: 2}, {X: 4, Y: 2}, {X: 3, Y: 2}}, found[1].Points)
+	// the diagonal pixels are 8-connected
+	comparePoints(t, []image.Point{{X: 0, Y: 4}, {X: 1, Y: 5}}, found[2].Points)
+}
+
+// nested is a ring with an island in its hole and a second component next to it.
+var nested = []string{
+	"...........",
+	".#######...",
+	".#.....#.#.",
+	".#.###.#...",
+	".#.###.#...",
+	".#.....#...",
+	".#######...",
+	"...........",
+}
+
+func Test_FindContours_Hierarchy(t *testing.T) {
+	found, err := FindContours(binaryFromRows(nested), ModeTree)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(found) != 4 {
+		t.Fatalf("Expected 4 contours - actual %d", len(found))
+	}
+	expected := []struct {
+		hole   bool
+		parent int
+		first  image.Point
+		length int
+	}{
+		{false, -1, image.Point{X: 1, Y: 1}, 22},
+		// the corners of the ring only touch the hole diagonally
+		{true, 0, image.Point{X: 1, Y: 2}, 18},
+		{false, -1, image.Point{X: 9, Y: 2}, 1},
+		{false, 1, image.Point{X: 3, Y: 3}, 6},
+	}
+	for i, e := range expected {
+		c := found[i]
+		if c.Hole != e.hole || c.Parent != e.parent || c.Points[0] != e.first || len(c.Points) != e.length {
+			t.Errorf("Contour %d: expected hole %v, parent %d, first point %v, %d points - actual hole %v, parent %d, "+
+				"first point %v, %d points", i, e.hole, e.parent, e.first, e.length, c.Hole, c.Parent, c.Points[0],
+				len(c.Points))
+		}
+	}
+}
+
+func Test_FindContours_Modes(t *testing.T) {
+	img := binaryFromRows(nested)
+	external, err := FindContours(img, ModeExternal)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(external) != 2 || external[0].Points[0] != (image.Point{X: 1, Y: 1}) ||
+		external[1].Points[0] != (image.Point{X: 9, Y: 2}) {
+		t.Errorf("Expected the ring and the pixel next to it - actual %+v", external)
+	}
+	list, err := FindContours(img, ModeList)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list) != 4 {
+		t.Fatalf("Expected 4 contours - actual %d", len(list))
+	}
+	for _, c := range list {
+		if c.Parent != -1 {
+			t.Errorf("Expected no hierarchy - actual parent %d", c.Parent)
+		}
+	}
+	if _, err := FindContours(img, Mode(42)); err == nil {
+		t.Error("Expected error for invalid mode")
+	}
+}
+
+func Test_FindContours_Cropped(t *testing.T) {
+	img := binaryFromRows([]string{
+		"#.....",
+		"..##..",
+		"..##..",
+		"......",
+	})
+	found, err := FindContours(img.SubImage(image.Rect(1, 1, 6, 4)).(*image.Gray), ModeTree)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 contour - actual %d", len(found))
+	}
+	comparePoints(t, []image.Point{{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 0}}, found[0].Points)
+}
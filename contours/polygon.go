@@ -0,0 +1,322 @@
+package contours
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// PointF is a point with floating point coordinates.
+type PointF struct {
+	X float64
+	Y float64
+}
+
+// RotatedRect is a rectangle which may be rotated around its center.
+type RotatedRect struct {
+	// Center is the center of the rectangle
+	Center PointF
+	// Width is the length of the sides parallel to the direction given by Angle
+	Width float64
+	// Height is the length of the sides perpendicular to the direction given by Angle
+	Height float64
+	// Angle is the direction of the Width sides in radians, measured from the x axis towards the y axis
+	Angle float64
+}
+
+// Corners returns the four corners of the rectangle in the order they follow each other.
+func (r RotatedRect) Corners() [4]PointF {
+	cos, sin := math.Cos(r.Angle), math.Sin(r.Angle)
+	// half of the width and height sides as vectors
+	wx, wy := cos*r.Width/2, sin*r.Width/2
+	hx, hy := -sin*r.Height/2, cos*r.Height/2
+	return [4]PointF{
+		{X: r.Center.X - wx - hx, Y: r.Center.Y - wy - hy},
+		{X: r.Center.X + wx - hx, Y: r.Center.Y + wy - hy},
+		{X: r.Center.X + wx + hx, Y: r.Center.Y + wy + hy},
+		{X: r.Center.X - wx + hx, Y: r.Center.Y - wy + hy},
+	}
+}
+
+// ArcLength returns the length of a polyline, or the perimeter of a polygon if closed is true.
+// Example of usage:
+//
+//	perimeter := contours.ArcLength(c.Points, true)
+func ArcLength(points []image.Point, closed bool) float64 {
+	var length float64
+	for i := 1; i < len(points); i++ {
+		length += distance(points[i-1], points[i])
+	}
+	if closed && len(points) > 1 {
+		length += distance(points[len(points)-1], points[0])
+	}
+	return length
+}
+
+// Area returns the area enclosed by a polygon, computed using the shoelace formula. The polygon goes through the
+// centers of the pixels, so the area of a traced contour is smaller than the number of its pixels.
+// Example of usage:
+//
+//	area := contours.Area(c.Points)
+func Area(points []image.Point) float64 {
+	var sum int
+	for i := range points {
+		p, q := points[i], points[(i+1)%len(points)]
+		sum += p.X*q.Y - q.X*p.Y
+	}
+	return math.Abs(float64(sum)) / 2
+}
+
+// ApproxPolyDP simplifies a polyline (or a polygon if closed is true) using the Douglas-Peucker algorithm: the
+// returned points are a subset of the input, such that no input point is farther than epsilon from the simplified
+// shape. A closed polygon is split at the point farthest from its first point, so both halves are simplified as open
+// polylines.
+// Example of usage:
+//
+//	// a document outline is expected to become 4 points
+//	quad := contours.ApproxPolyDP(c.Points, 0.02*contours.ArcLength(c.Points, true), true)
+func ApproxPolyDP(points []image.Point, epsilon float64, closed bool) []image.Point {
+	if len(points) < 3 {
+		return append([]image.Point(nil), points...)
+	}
+	if !closed {
+		return douglasPeucker(points, epsilon)
+	}
+	farthest := 0
+	for i, p := range points {
+		if distance(points[0], p) > distance(points[0], points[farthest]) {
+			farthest = i
+		}
+	}
+	if farthest == 0 {
+		// every point is at the same position
+		return []image.Point{points[0]}
+	}
+	first := douglasPeucker(points[:farthest+1], epsilon)
+	second := douglasPeucker(append(append([]image.Point(nil), points[farthest:]...), points[0]), epsilon)
+	// the split points are the last point of each half
+	return append(first[:len(first)-1], second[:len(second)-1]...)
+}
+
+// ConvexHull returns the smallest convex polygon containing every point, using Andrew's monotone chain algorithm. The
+// vertices are returned in clockwise order as displayed (with the y axis pointing downwards), starting with the
+// leftmost one. The points on the edges of the hull are not included.
+// Example of usage:
+//
+//	hull := contours.ConvexHull(c.Points)
+func ConvexHull(points []image.Point) []image.Point {
+	sorted := append([]image.Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	// remove the duplicates
+	unique := sorted[:0]
+	for i, p := range sorted {
+		if i == 0 || p != sorted[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	if len(unique) < 3 {
+		return unique
+	}
+	hull := make([]image.Point, 0, 2*len(unique))
+	for _, p := range unique {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(unique) - 2; i >= 0; i-- {
+		p := unique[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// the last point is the first one again
+	return hull[:len(hull)-1]
+}
+
+// MinAreaRect returns the rotated rectangle with the smallest area which contains every point. One side of the
+// optimal rectangle lies on an edge of the convex hull, so every edge of the hull is tried (rotating calipers).
+// Example of usage:
+//
+//	rect := contours.MinAreaRect(c.Points)
+//	corners := rect.Corners()
+func MinAreaRect(points []image.Point) RotatedRect {
+	hull := ConvexHull(points)
+	switch len(hull) {
+	case 0:
+		return RotatedRect{}
+	case 1:
+		return RotatedRect{Center: toPointF(hull[0])}
+	case 2:
+		a, b := toPointF(hull[0]), toPointF(hull[1])
+		return RotatedRect{
+			Center: PointF{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2},
+			Width:  math.Hypot(b.X-a.X, b.Y-a.Y),
+			Angle:  math.Atan2(b.Y-a.Y, b.X-a.X),
+		}
+	}
+	best := RotatedRect{}
+	bestArea := math.Inf(1)
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		angle := math.Atan2(float64(b.Y-a.Y), float64(b.X-a.X))
+		ux, uy := math.Cos(angle), math.Sin(angle)
+		// project the hull onto the edge direction (u) and its normal (v)
+		minU, maxU, minV, maxV := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+		for _, p := range hull {
+			u := float64(p.X)*ux + float64(p.Y)*uy
+			v := -float64(p.X)*uy + float64(p.Y)*ux
+			minU, maxU = math.Min(minU, u), math.Max(maxU, u)
+			minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+		}
+		area := (maxU - minU) * (maxV - minV)
+		if area < bestArea {
+			bestArea = area
+			cu, cv := (minU+maxU)/2, (minV+maxV)/2
+			best = RotatedRect{
+				Center: PointF{X: cu*ux - cv*uy, Y: cu*uy + cv*ux},
+				Width:  maxU - minU,
+				Height: maxV - minV,
+				Angle:  angle,
+			}
+		}
+	}
+	return best
+}
+
+// MinEnclosingCircle returns the center and the radius of the smallest circle which contains every point, using
+// Welzl's algorithm in its iterative form. The points are processed in a random (but reproducible) order, which makes
+// the expected running time linear.
+// Example of usage:
+//
+//	center, radius := contours.MinEnclosingCircle(c.Points)
+func MinEnclosingCircle(points []image.Point) (PointF, float64) {
+	if len(points) == 0 {
+		return PointF{}, 0
+	}
+	shuffled := make([]PointF, len(points))
+	for i, p := range points {
+		shuffled[i] = toPointF(p)
+	}
+	r := rand.New(rand.NewSource(1))
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	center, radius := shuffled[0], 0.0
+	for i := 1; i < len(shuffled); i++ {
+		if inCircle(shuffled[i], center, radius) {
+			continue
+		}
+		// shuffled[i] is on the boundary of the circle of the first i+1 points
+		center, radius = shuffled[i], 0
+		for j := 0; j < i; j++ {
+			if inCircle(shuffled[j], center, radius) {
+				continue
+			}
+			// shuffled[i] and shuffled[j] are on the boundary
+			center, radius = circleFrom2(shuffled[i], shuffled[j])
+			for k := 0; k < j; k++ {
+				if !inCircle(shuffled[k], center, radius) {
+					center, radius = circleFrom3(shuffled[i], shuffled[j], shuffled[k])
+				}
+			}
+		}
+	}
+	return center, radius
+}
+
+// -------------------------------------------------------------------------------------------------------
+// circleEpsilon is the tolerance of the containment test of MinEnclosingCircle.
+const circleEpsilon = 1e-7
+
+func toPointF(p image.Point) PointF {
+	return PointF{X: float64(p.X), Y: float64(p.Y)}
+}
+
+func distance(a image.Point, b image.Point) float64 {
+	return math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y))
+}
+
+// cross returns the z component of the cross product of the vectors o->a and o->b.
+func cross(o image.Point, a image.Point, b image.Point) int {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// segmentDistance returns the distance of p from the segment a-b.
+func segmentDistance(p image.Point, a image.Point, b image.Point) float64 {
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return distance(p, a)
+	}
+	t := (float64(p.X-a.X)*dx + float64(p.Y-a.Y)*dy) / length
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(float64(p.X-a.X)-t*dx, float64(p.Y-a.Y)-t*dy)
+}
+
+// douglasPeucker simplifies an open polyline, keeping its first and last points. It uses an explicit stack of ranges,
+// so long contours do not cause deep recursion.
+func douglasPeucker(points []image.Point, epsilon float64) []image.Point {
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	stack := [][2]int{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		r := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		farthest, maxDistance := -1, epsilon
+		for i := r[0] + 1; i < r[1]; i++ {
+			if d := segmentDistance(points[i], points[r[0]], points[r[1]]); d > maxDistance {
+				farthest, maxDistance = i, d
+			}
+		}
+		if farthest >= 0 {
+			keep[farthest] = true
+			stack = append(stack, [2]int{r[0], farthest}, [2]int{farthest, r[1]})
+		}
+	}
+	var res []image.Point
+	for i, p := range points {
+		if keep[i] {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+func inCircle(p PointF, center PointF, radius float64) bool {
+	return math.Hypot(p.X-center.X, p.Y-center.Y) <= radius+circleEpsilon
+}
+
+func circleFrom2(a PointF, b PointF) (PointF, float64) {
+	center := PointF{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+	return center, math.Hypot(a.X-center.X, a.Y-center.Y)
+}
+
+// circleFrom3 returns the circumscribed circle of a triangle. For collinear points the circle of the two farthest
+// points is returned.
+func circleFrom3(a PointF, b PointF, c PointF) (PointF, float64) {
+	bx, by := b.X-a.X, b.Y-a.Y
+	cx, cy := c.X-a.X, c.Y-a.Y
+	d := 2 * (bx*cy - by*cx)
+	if d == 0 {
+		center, radius := circleFrom2(a, b)
+		for _, pair := range [][2]PointF{{a, c}, {b, c}} {
+			if cc, cr := circleFrom2(pair[0], pair[1]); cr > radius {
+				center, radius = cc, cr
+			}
+		}
+		return center, radius
+	}
+	ux := (cy*(bx*bx+by*by) - by*(cx*cx+cy*cy)) / d
+	uy := (bx*(cx*cx+cy*cy) - cx*(bx*bx+by*by)) / d
+	return PointF{X: a.X + ux, Y: a.Y + uy}, math.Hypot(ux, uy)
+}
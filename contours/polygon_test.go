@@ -0,0 +1,117 @@
+package contours
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+var rectangle = []image.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 3}, {X: 0, Y: 3}}
+
+func Test_Area_ArcLength(t *testing.T) {
+	if area := Area(rectangle); !utils.IsEqualFloat64(area, 12) {
+		t.Errorf("Expected area 12 - actual %f", area)
+	}
+	if length := ArcLength(rectangle, true); !utils.IsEqualFloat64(length, 14) {
+		t.Errorf("Expected perimeter 14 - actual %f", length)
+	}
+	if length := ArcLength(rectangle, false); !utils.IsEqualFloat64(length, 11) {
+		t.Errorf("Expected length 11 - actual %f", length)
+	}
+}
+
+func Test_ApproxPolyDP_Open(t *testing.T) {
+	line := []image.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 1}, {X: 3, Y: 0}, {X: 4, Y: 0}, {X: 5, Y: 4},
+		{X: 6, Y: 8}}
+	comparePoints(t, []image.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 6, Y: 8}}, ApproxPolyDP(line, 1.5, false))
+	// (1, 0) and (3, 0) are closer than 0.5 to the simplified line, (5, 4) lies on it
+	comparePoints(t, []image.Point{{X: 0, Y: 0}, {X: 2, Y: 1}, {X: 4, Y: 0}, {X: 6, Y: 8}},
+		ApproxPolyDP(line, 0.5, false))
+}
+
+func Test_ApproxPolyDP_ClosedContour(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 20, 12))
+	for y := 2; y < 10; y++ {
+		for x := 3; x < 17; x++ {
+			img.Pix[y*img.Stride+x] = 255
+		}
+	}
+	found, _ := FindContours(img, ModeExternal)
+	points := found[0].Points
+	approx := ApproxPolyDP(points, 0.02*ArcLength(points, true), true)
+	comparePoints(t, []image.Point{{X: 3, Y: 2}, {X: 3, Y: 9}, {X: 16, Y: 9}, {X: 16, Y: 2}}, approx)
+	if area := Area(approx); !utils.IsEqualFloat64(area, 13*7) {
+		t.Errorf("Expected area 91 - actual %f", area)
+	}
+}
+
+func Test_ConvexHull(t *testing.T) {
+	points := []image.Point{{X: 2, Y: 2}, {X: 4, Y: 4}, {X: 0, Y: 0}, {X: 2, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 4},
+		{X: 1, Y: 3}, {X: 4, Y: 4}}
+	comparePoints(t, []image.Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 4}}, ConvexHull(points))
+	comparePoints(t, []image.Point{{X: 1, Y: 1}}, ConvexHull([]image.Point{{X: 1, Y: 1}, {X: 1, Y: 1}}))
+}
+
+func Test_MinAreaRect(t *testing.T) {
+	diamond := []image.Point{{X: 0, Y: 5}, {X: 5, Y: 0}, {X: 10, Y: 5}, {X: 5, Y: 10}, {X: 5, Y: 5}, {X: 3, Y: 4}}
+	rect := MinAreaRect(diamond)
+	if !utils.IsEqualFloat64(rect.Center.X, 5) || !utils.IsEqualFloat64(rect.Center.Y, 5) ||
+		!utils.IsEqualFloat64(rect.Width*rect.Height, 50) || !utils.IsEqualFloat64(rect.Width, 5*math.Sqrt2) {
+		t.Errorf("Expected a 45 degree square with area 50 around (5, 5) - actual %+v", rect)
+	}
+	for _, corner := range rect.Corners() {
+		found := false
+		for _, p := range diamond {
+			if utils.IsEqualFloat64(corner.X, float64(p.X)) && utils.IsEqualFloat64(corner.Y, float64(p.Y)) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected the corners to be the vertices of the diamond - actual %v", rect.Corners())
+			break
+		}
+	}
+	rect = MinAreaRect(rectangle)
+	if !utils.IsEqualFloat64(rect.Width*rect.Height, 12) || !utils.IsEqualFloat64(rect.Center.X, 2) ||
+		!utils.IsEqualFloat64(rect.Center.Y, 1.5) {
+		t.Errorf("Expected the rectangle itself - actual %+v", rect)
+	}
+}
+
+func Test_MinEnclosingCircle(t *testing.T) {
+	center, radius := MinEnclosingCircle(append([]image.Point{{X: 2, Y: 1}, {X: 1, Y: 3}}, rectangle...))
+	if !utils.IsEqualFloat64(center.X, 2) || !utils.IsEqualFloat64(center.Y, 1.5) ||
+		!utils.IsEqualFloat64(radius, 2.5) {
+		t.Errorf("Expected circle (2, 1.5) with radius 2.5 - actual %v, %f", center, radius)
+	}
+	// an obtuse triangle is enclosed by the circle over its longest side
+	center, radius = MinEnclosingCircle([]image.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 5, Y: 1}})
+	if !utils.IsEqualFloat64(center.X, 5) || !utils.IsEqualFloat64(center.Y, 0) || !utils.IsEqualFloat64(radius, 5) {
+		t.Errorf("Expected circle (5, 0) with radius 5 - actual %v, %f", center, radius)
+	}
+
+	r := rand.New(rand.NewSource(7))
+	points := make([]image.Point, 200)
+	for i := range points {
+		points[i] = image.Point{X: r.Intn(100), Y: r.Intn(60)}
+	}
+	center, radius = MinEnclosingCircle(points)
+	onBoundary := 0
+	for _, p := range points {
+		d := math.Hypot(float64(p.X)-center.X, float64(p.Y)-center.Y)
+		if d > radius+1e-6 {
+			t.Fatalf("Point %v is outside of the circle %v, %f", p, center, radius)
+		}
+		if d > radius-1e-6 {
+			onBoundary++
+		}
+	}
+	if onBoundary < 2 {
+		t.Errorf("Expected at least 2 points on the boundary of the smallest circle - actual %d", onBoundary)
+	}
+}
@@ -1,6 +1,7 @@
 package convolution
 
 import (
+	"context"
 	"image"
 	"image/color"
 
@@ -14,20 +15,36 @@ import (
 //	res, err := convolution.ConvolveGray(img, kernel, {1, 1}, BorderReflect)
 //
 // Note: the anchor represents a point inside the area of the kernel. After every step of the convolution the position
-// specified by the anchor point gets updated on the result image.
+// specified by the anchor point gets updated on the result image. Kernels with an area of at least FFTKernelArea are
+// applied using ConvolveFFTGray.
 func ConvolveGray(img *image.Gray, kernel *Kernel, anchor image.Point, border padding.Border) (*image.Gray, error) {
+	return ConvolveGrayCtx(context.Background(), img, kernel, anchor, border, nil)
+}
+
+// ConvolveGrayCtx is ConvolveGray which can be aborted using ctx. The context is checked between the rows of the
+// result, ctx.Err() is returned if it was cancelled.
+func ConvolveGrayCtx(ctx context.Context, img *image.Gray, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
+	if useFFT(kernel.Size()) {
+		return ConvolveFFTGrayCtx(ctx, img, kernel, anchor, border, opts)
+	}
+	return convolveSpatialGray(ctx, img, kernel, anchor, border, opts)
+}
+
+func convolveSpatialGray(ctx context.Context, img *image.Gray, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
 	kernelSize := kernel.Size()
-	padded, err := padding.PaddingGray(img, kernelSize, anchor, border)
+	sampler, err := newGraySampler(img, kernelSize, anchor, border, opts)
 	if err != nil {
 		return nil, err
 	}
 	originalSize := img.Bounds().Size()
 	resultImage := image.NewGray(image.Rect(0, 0, originalSize.X, originalSize.Y))
-	utils.IteratePixels(originalSize, func(x, y int) {
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), originalSize, func(x, y int) {
 		sum := float64(0)
 		for ky := 0; ky < kernelSize.Y; ky++ {
 			for kx := 0; kx < kernelSize.X; kx++ {
-				pixel := padded.GrayAt(x+kx, y+ky)
+				pixel := sampler.GrayAt(x+kx-anchor.X, y+ky-anchor.Y)
 				kE := kernel.At(kx, ky)
 				sum += float64(pixel.Y) * kE
 			}
@@ -35,6 +52,9 @@ func ConvolveGray(img *image.Gray, kernel *Kernel, anchor image.Point, border pa
 		sum = utils.ClampF64(sum, utils.MinUint8, float64(utils.MaxUint8))
 		resultImage.Set(x, y, color.Gray{Y: uint8(sum)})
 	})
+	if err != nil {
+		return nil, err
+	}
 	return resultImage, nil
 }
 
@@ -44,21 +64,37 @@ func ConvolveGray(img *image.Gray, kernel *Kernel, anchor image.Point, border pa
 //	res, err := convolution.ConvolveRGBA(img, kernel, {1, 1}, BorderReflect)
 //
 // Note: the anchor represents a point inside the area of the kernel. After every step of the convolution the position
-// specified by the anchor point gets updated on the result image.
+// specified by the anchor point gets updated on the result image. Kernels with an area of at least FFTKernelArea are
+// applied using ConvolveFFTRGBA.
 func ConvolveRGBA(img *image.RGBA, kernel *Kernel, anchor image.Point, border padding.Border) (*image.RGBA, error) {
+	return ConvolveRGBACtx(context.Background(), img, kernel, anchor, border, nil)
+}
+
+// ConvolveRGBACtx is ConvolveRGBA which can be aborted using ctx. The context is checked between the rows of the
+// result, ctx.Err() is returned if it was cancelled.
+func ConvolveRGBACtx(ctx context.Context, img *image.RGBA, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
+	if useFFT(kernel.Size()) {
+		return ConvolveFFTRGBACtx(ctx, img, kernel, anchor, border, opts)
+	}
+	return convolveSpatialRGBA(ctx, img, kernel, anchor, border, opts)
+}
+
+func convolveSpatialRGBA(ctx context.Context, img *image.RGBA, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
 	kernelSize := kernel.Size()
-	padded, err := padding.PaddingRGBA(img, kernelSize, anchor, border)
+	sampler, err := newRGBASampler(img, kernelSize, anchor, border, opts)
 	if err != nil {
 		return nil, err
 	}
 	originalSize := img.Bounds().Size()
 	offset := img.Bounds().Min
 	resultImage := image.NewRGBA(image.Rect(0, 0, originalSize.X, originalSize.Y))
-	utils.IteratePixels(originalSize, func(x int, y int) {
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), originalSize, func(x int, y int) {
 		sumR, sumG, sumB := 0.0, 0.0, 0.0
 		for kx := 0; kx < kernelSize.X; kx++ {
 			for ky := 0; ky < kernelSize.Y; ky++ {
-				pixel := padded.RGBAAt(x+kx, y+ky)
+				pixel := sampler.RGBAAt(x+kx-anchor.X, y+ky-anchor.Y)
 				sumR += float64(pixel.R) * kernel.At(kx, ky)
 				sumG += float64(pixel.G) * kernel.At(kx, ky)
 				sumB += float64(pixel.B) * kernel.At(kx, ky)
@@ -70,5 +106,29 @@ func ConvolveRGBA(img *image.RGBA, kernel *Kernel, anchor image.Point, border pa
 		rgba := img.RGBAAt(x+offset.X, y+offset.Y)
 		resultImage.Set(x, y, color.RGBA{uint8(sumR), uint8(sumG), uint8(sumB), rgba.A})
 	})
+	if err != nil {
+		return nil, err
+	}
 	return resultImage, nil
 }
+
+// -------------------------------------------------------------------------------------------------------
+// newGraySampler checks the kernel size and the anchor and returns a sampler which reads the image using the given
+// border and the fill color of opts, so the image does not have to be padded.
+func newGraySampler(img *image.Gray, kernelSize image.Point, anchor image.Point, border padding.Border,
+	opts *utils.Options) (*padding.GraySampler, error) {
+	if _, err := padding.NewPaddings(kernelSize, anchor); err != nil {
+		return nil, err
+	}
+	return padding.NewGraySamplerWithFill(img, border, opts.FillOrDefault())
+}
+
+// newRGBASampler checks the kernel size and the anchor and returns a sampler which reads the image using the given
+// border and the fill color of opts, so the image does not have to be padded.
+func newRGBASampler(img *image.RGBA, kernelSize image.Point, anchor image.Point, border padding.Border,
+	opts *utils.Options) (*padding.RGBASampler, error) {
+	if _, err := padding.NewPaddings(kernelSize, anchor); err != nil {
+		return nil, err
+	}
+	return padding.NewRGBASamplerWithFill(img, border, opts.FillOrDefault())
+}
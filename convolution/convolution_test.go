@@ -1,6 +1,7 @@
 package convolution
 
 import (
+	"context"
 	"image"
 	"testing"
 
@@ -183,3 +184,47 @@ func Test_ConvoleRGBA_11Kernel(t *testing.T) {
 }
 
 // -------------------------------------------------------------------------------
+
+func Test_ConvolveGrayCtx_Cancelled(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 16, 16))
+	kernel := Kernel{[][]float64{
+		{0, 0, 0},
+		{0, 1, 0},
+		{0, 0, 0},
+	}, 3, 3}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	anchor := image.Point{X: 1, Y: 1}
+	if _, err := ConvolveGrayCtx(ctx, gray, &kernel, anchor, padding.BorderConstant, nil); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	if _, err := ConvolveRGBACtx(ctx, rgba, &kernel, anchor, padding.BorderConstant, nil); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+}
+
+// countingExecutor processes the rows sequentially and counts the calls of IterateRows.
+type countingExecutor struct {
+	calls int
+}
+
+func (c *countingExecutor) IterateRows(height int, f func(startY, endY int)) {
+	c.calls++
+	utils.Sequential.IterateRows(height, f)
+}
+
+func Test_ConvolveGrayCtx_UsesExecutorOfOptions(t *testing.T) {
+	gray := &image.Gray{Rect: image.Rect(0, 0, 3, 2), Stride: 3, Pix: []uint8{1, 2, 3, 4, 5, 6}}
+	kernel := Kernel{[][]float64{{1}}, 1, 1}
+	executor := &countingExecutor{}
+	opts := &utils.Options{Executor: executor}
+	res, err := ConvolveGrayCtx(context.Background(), gray, &kernel, image.Point{}, padding.BorderConstant, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	utils.CompareGrayImages(t, gray, res)
+	if executor.calls == 0 {
+		t.Error("Expected the rows to be processed by the executor of the options")
+	}
+}
@@ -0,0 +1,225 @@
+package convolution
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"github.com/ernyoke/imger/fft"
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// FFTKernelArea is the kernel area (width * height) starting from which ConvolveGray and ConvolveRGBA compute the
+// convolution in the frequency domain instead of the spatial domain. The spatial convolution costs
+// O(width * height) operations per pixel, while the cost of the FFT does not depend on the size of the kernel.
+const FFTKernelArea = 15 * 15
+
+// ConvolveFFTGray applies a convolution matrix (kernel) to a grayscale image by multiplying their spectrums. The
+// result is the same as the result of ConvolveGray (up to rounding), but for large kernels it is computed much faster.
+// The anchor and the border have the same meaning as for ConvolveGray.
+// Example of usage:
+//
+//	res, err := convolution.ConvolveFFTGray(img, kernel, {15, 15}, BorderReflect)
+func ConvolveFFTGray(img *image.Gray, kernel *Kernel, anchor image.Point, border padding.Border) (*image.Gray, error) {
+	return ConvolveFFTGrayCtx(context.Background(), img, kernel, anchor, border, nil)
+}
+
+// ConvolveFFTGrayCtx is ConvolveFFTGray which can be aborted using ctx. The context is checked between the transforms,
+// ctx.Err() is returned if it was cancelled.
+func ConvolveFFTGrayCtx(ctx context.Context, img *image.Gray, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
+	sampler, err := newGraySampler(img, kernel.Size(), anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	originalSize := img.Bounds().Size()
+	plan, err := newFFTPlan(originalSize, kernel, anchor)
+	if err != nil {
+		return nil, err
+	}
+	resultImage := image.NewGray(image.Rect(0, 0, originalSize.X, originalSize.Y))
+	err = plan.correlate(ctx, opts.ExecutorOrDefault(), func(x, y int) uint8 {
+		return sampler.GrayAt(x, y).Y
+	}, func(x, y int, value float64) {
+		resultImage.Pix[y*resultImage.Stride+x] = fftToUint8(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resultImage, nil
+}
+
+// ConvolveFFTRGBA applies a convolution matrix (kernel) to an RGBA image by multiplying their spectrums. See
+// ConvolveFFTGray. The alpha channel is kept unchanged, as for ConvolveRGBA.
+// Example of usage:
+//
+//	res, err := convolution.ConvolveFFTRGBA(img, kernel, {15, 15}, BorderReflect)
+func ConvolveFFTRGBA(img *image.RGBA, kernel *Kernel, anchor image.Point, border padding.Border) (*image.RGBA, error) {
+	return ConvolveFFTRGBACtx(context.Background(), img, kernel, anchor, border, nil)
+}
+
+// ConvolveFFTRGBACtx is ConvolveFFTRGBA which can be aborted using ctx. The context is checked between the transforms,
+// ctx.Err() is returned if it was cancelled.
+func ConvolveFFTRGBACtx(ctx context.Context, img *image.RGBA, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
+	sampler, err := newRGBASampler(img, kernel.Size(), anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	originalSize := img.Bounds().Size()
+	plan, err := newFFTPlan(originalSize, kernel, anchor)
+	if err != nil {
+		return nil, err
+	}
+	offset := img.Bounds().Min
+	resultImage := image.NewRGBA(image.Rect(0, 0, originalSize.X, originalSize.Y))
+	channels := []func(color.RGBA) uint8{
+		func(c color.RGBA) uint8 { return c.R },
+		func(c color.RGBA) uint8 { return c.G },
+		func(c color.RGBA) uint8 { return c.B },
+	}
+	for c, channel := range channels {
+		err := plan.correlate(ctx, opts.ExecutorOrDefault(), func(x, y int) uint8 {
+			return channel(sampler.RGBAAt(x, y))
+		}, func(x, y int, value float64) {
+			resultImage.Pix[y*resultImage.Stride+4*x+c] = fftToUint8(value)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), originalSize, func(x, y int) {
+		resultImage.Pix[y*resultImage.Stride+4*x+3] = img.RGBAAt(x+offset.X, y+offset.Y).A
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resultImage, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// useFFT decides whether a kernel of the given size is applied in the frequency domain.
+func useFFT(kernelSize image.Point) bool {
+	return kernelSize.X*kernelSize.Y >= FFTKernelArea
+}
+
+// fftTileSize is the size of the part of the result which is computed by one transform, before it is rounded up to
+// fill the transform size. Correlating the image tile by tile (overlap-save) bounds the memory used by the transforms
+// independently of the size of the image.
+const fftTileSize = 256
+
+// fftPlan holds the spectrum of a kernel and the tiling of an image of a given size.
+type fftPlan struct {
+	width      int
+	height     int
+	size       image.Point
+	tile       image.Point
+	kernelSize image.Point
+	anchor     image.Point
+	spectrum   []complex128
+}
+
+// newFFTPlan prepares the spectrum of the kernel for correlating it with an image of the given size. A tile of the
+// result needs the tile extended by the size of the kernel from the image, the transform size is this extent rounded
+// up to a power of two, which is large enough for the circular correlation not to wrap around inside the tile.
+func newFFTPlan(size image.Point, kernel *Kernel, anchor image.Point) (*fftPlan, error) {
+	if kernel.Width == 0 || kernel.Height == 0 {
+		return nil, errors.New("empty kernel")
+	}
+	kernelSize := kernel.Size()
+	width := fft.NextPowerOfTwo(utils.ClampInt(size.X, 1, fftTileSize) + kernelSize.X - 1)
+	height := fft.NextPowerOfTwo(utils.ClampInt(size.Y, 1, fftTileSize) + kernelSize.Y - 1)
+	tile := image.Point{
+		X: utils.ClampInt(size.X, 1, width-kernelSize.X+1),
+		Y: utils.ClampInt(size.Y, 1, height-kernelSize.Y+1),
+	}
+	// the correlation with the kernel is the convolution with the mirrored kernel: k'(-x, -y) = k(x, y)
+	mirrored := make([]complex128, width*height)
+	for kx := 0; kx < kernel.Width; kx++ {
+		for ky := 0; ky < kernel.Height; ky++ {
+			x := (width - kx) % width
+			y := (height - ky) % height
+			mirrored[y*width+x] = complex(kernel.At(kx, ky), 0)
+		}
+	}
+	spectrum, err := fft.FFT2(mirrored, width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &fftPlan{width: width, height: height, size: size, tile: tile, kernelSize: kernelSize, anchor: anchor,
+		spectrum: spectrum}, nil
+}
+
+// correlate computes the correlation of the image and the kernel. at returns the value of the image at a position
+// relative to its top-left corner, including the border, and set receives every value of the result. The tiles are
+// distributed by the executor e and the context is checked before each tile.
+func (p *fftPlan) correlate(ctx context.Context, e utils.Executor, at func(x, y int) uint8,
+	set func(x, y int, value float64)) error {
+	tiles := image.Point{X: (p.size.X + p.tile.X - 1) / p.tile.X, Y: (p.size.Y + p.tile.Y - 1) / p.tile.Y}
+	var tileErr error
+	var errOnce sync.Once
+	err := utils.IteratePixelsCtx(ctx, e, tiles, func(tx, ty int) {
+		if err := p.correlateTile(ctx, image.Point{X: tx * p.tile.X, Y: ty * p.tile.Y}, at, set); err != nil {
+			errOnce.Do(func() { tileErr = err })
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return tileErr
+}
+
+// correlateTile computes the tile of the result whose top-left corner is origin.
+func (p *fftPlan) correlateTile(ctx context.Context, origin image.Point, at func(x, y int) uint8,
+	set func(x, y int, value float64)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tile := image.Point{
+		X: utils.ClampInt(p.size.X-origin.X, 0, p.tile.X),
+		Y: utils.ClampInt(p.size.Y-origin.Y, 0, p.tile.Y),
+	}
+	extent := tile.Add(p.kernelSize).Sub(image.Point{X: 1, Y: 1})
+	start := origin.Sub(p.anchor)
+	data := make([]complex128, p.width*p.height)
+	for y := 0; y < extent.Y; y++ {
+		for x := 0; x < extent.X; x++ {
+			data[y*p.width+x] = complex(float64(at(start.X+x, start.Y+y)), 0)
+		}
+	}
+	transformed, err := fft.FFT2(data, p.width, p.height)
+	if err != nil {
+		return err
+	}
+	for i := range transformed {
+		transformed[i] *= p.spectrum[i]
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	result, err := fft.IFFT2(transformed, p.width, p.height)
+	if err != nil {
+		return err
+	}
+	for y := 0; y < tile.Y; y++ {
+		for x := 0; x < tile.X; x++ {
+			set(origin.X+x, origin.Y+y, real(result[y*p.width+x]))
+		}
+	}
+	return nil
+}
+
+// fftEpsilon is the largest rounding error of the transforms which is removed before the result is truncated, so that
+// values which are integers in the spatial domain are not truncated to the integer below.
+const fftEpsilon = 1e-6
+
+func fftToUint8(v float64) uint8 {
+	if rounded := math.Round(v); math.Abs(v-rounded) < fftEpsilon {
+		v = rounded
+	}
+	return uint8(utils.ClampF64(v, utils.MinUint8, float64(utils.MaxUint8)))
+}
@@ -0,0 +1,156 @@
+package convolution
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests------------------------------------
+// largeKernel returns a normalized, non separable kernel
+func largeKernel(width int, height int) *Kernel {
+	kernel, _ := NewKernel(width, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			kernel.Set(x, y, 1+math.Abs(math.Sin(float64(x*y))))
+		}
+	}
+	return kernel.Normalize()
+}
+
+func Test_ConvolveFFTGray_MatchesSpatial(t *testing.T) {
+	img, err := imgio.ImreadGray("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	cropped := img.SubImage(image.Rect(50, 60, 150, 130)).(*image.Gray)
+	kernel := largeKernel(17, 15)
+	anchor := image.Point{X: 8, Y: 3}
+	ctx := context.Background()
+	for _, opts := range []*utils.Options{nil, {Fill: color.White}} {
+		for _, border := range []padding.Border{padding.BorderConstant, padding.BorderReplicate, padding.BorderReflect,
+			padding.BorderWrap} {
+			expected, err := convolveSpatialGray(ctx, cropped, kernel, anchor, border, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := ConvolveFFTGrayCtx(ctx, cropped, kernel, anchor, border, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			utils.CompareGrayImagesWithOffset(t, expected, actual, 1)
+		}
+	}
+}
+
+func Test_ConvolveFFTRGBA_MatchesSpatial(t *testing.T) {
+	img, err := imgio.ImreadRGBA("../res/girl.jpg")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	cropped := img.SubImage(image.Rect(100, 100, 180, 160)).(*image.RGBA)
+	kernel := largeKernel(15, 16)
+	anchor := image.Point{X: 7, Y: 7}
+	expected, err := convolveSpatialRGBA(context.Background(), cropped, kernel, anchor, padding.BorderReflect, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ConvolveFFTRGBA(cropped, kernel, anchor, padding.BorderReflect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareRGBAImagesWithOffset(t, expected, actual, 1)
+}
+
+func Test_ConvolveFFTGray_IdentityKernel(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 23, 19))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	kernel, _ := NewKernel(15, 15)
+	kernel.Set(7, 7, 1)
+	actual, err := ConvolveFFTGray(img, kernel, image.Point{X: 7, Y: 7}, padding.BorderConstant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImages(t, img, actual)
+}
+
+func Test_ConvolveGray_SelectsFFT(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 40, 30))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 13)
+	}
+	kernel := largeKernel(15, 15)
+	anchor := image.Point{X: 7, Y: 7}
+	expected, err := ConvolveFFTGray(img, kernel, anchor, padding.BorderReplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ConvolveGray(img, kernel, anchor, padding.BorderReplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImages(t, expected, actual)
+}
+
+func Test_ConvolveFFTGrayCtx_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	_, err := ConvolveFFTGrayCtx(ctx, img, largeKernel(15, 15), image.Point{X: 7, Y: 7}, padding.BorderConstant, nil)
+	if err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+}
+
+func Test_ConvolveFFTGray_Tiles(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 620, 560))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i*i*31 + i)
+	}
+	cropped := img.SubImage(image.Rect(3, 5, 610, 557)).(*image.Gray)
+	kernel := largeKernel(15, 17)
+	anchor := image.Point{X: 4, Y: 9}
+	plan, err := newFFTPlan(cropped.Bounds().Size(), kernel, anchor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.tile.X >= 607 || plan.tile.Y >= 552 {
+		t.Fatalf("Expected the image to be split into several tiles - actual tile size %v", plan.tile)
+	}
+	expected, err := convolveSpatialGray(context.Background(), cropped, kernel, anchor, padding.BorderReflect, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &utils.Options{Executor: utils.NewParallelExecutor(3)}
+	actual, err := ConvolveFFTGrayCtx(context.Background(), cropped, kernel, anchor, padding.BorderReflect, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImagesWithOffset(t, expected, actual, 1)
+}
+
+func Test_NewFFTPlan_TransformSizeDoesNotDependOnImageSize(t *testing.T) {
+	plan, err := newFFTPlan(image.Point{X: 4000, Y: 3000}, largeKernel(31, 31), image.Point{X: 15, Y: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.width > 512 || plan.height > 512 {
+		t.Errorf("Expected a transform size of at most 512x512 - actual %dx%d", plan.width, plan.height)
+	}
+}
+
+func Test_ConvolveFFTGray_EmptyKernel(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 3))
+	kernel, _ := NewKernel(0, 0)
+	if _, err := ConvolveFFTGray(img, kernel, image.Point{}, padding.BorderConstant); err == nil {
+		t.Error("Expected an error for an empty kernel")
+	}
+}
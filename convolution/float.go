@@ -0,0 +1,65 @@
+package convolution
+
+import (
+	"context"
+	"image"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ConvolveGrayFloat applies a convolution matrix (kernel) to a grayscale image and returns the sums without clamping
+// them, so negative and large responses (for example the ones of a Sobel or Laplacian kernel) are kept. The result is
+// a single channel Float32Image, which can be converted back to a grayscale image using its ToGray method. Kernels
+// with an area of at least FFTKernelArea are applied in the frequency domain, as for ConvolveGray.
+// Example of usage:
+//
+//	res, err := convolution.ConvolveGrayFloat(img, kernel, {1, 1}, BorderReflect)
+//	gray, err := res.ToGray(utils.ConvertAbs)
+func ConvolveGrayFloat(img *image.Gray, kernel *Kernel, anchor image.Point,
+	border padding.Border) (*utils.Float32Image, error) {
+	return ConvolveGrayFloatCtx(context.Background(), img, kernel, anchor, border, nil)
+}
+
+// ConvolveGrayFloatCtx is ConvolveGrayFloat which can be aborted using ctx. ctx.Err() is returned if it was cancelled.
+func ConvolveGrayFloatCtx(ctx context.Context, img *image.Gray, kernel *Kernel, anchor image.Point,
+	border padding.Border, opts *utils.Options) (*utils.Float32Image, error) {
+	kernelSize := kernel.Size()
+	sampler, err := newGraySampler(img, kernelSize, anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	originalSize := img.Bounds().Size()
+	resultImage, err := utils.NewFloat32Image(originalSize.X, originalSize.Y, 1)
+	if err != nil {
+		return nil, err
+	}
+	if useFFT(kernelSize) {
+		plan, err := newFFTPlan(originalSize, kernel, anchor)
+		if err != nil {
+			return nil, err
+		}
+		err = plan.correlate(ctx, opts.ExecutorOrDefault(), func(x, y int) uint8 {
+			return sampler.GrayAt(x, y).Y
+		}, func(x, y int, value float64) {
+			resultImage.Pix[y*originalSize.X+x] = float32(value)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resultImage, nil
+	}
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), originalSize, func(x, y int) {
+		sum := float64(0)
+		for ky := 0; ky < kernelSize.Y; ky++ {
+			for kx := 0; kx < kernelSize.X; kx++ {
+				sum += float64(sampler.GrayAt(x+kx-anchor.X, y+ky-anchor.Y).Y) * kernel.At(kx, ky)
+			}
+		}
+		resultImage.Pix[y*originalSize.X+x] = float32(sum)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resultImage, nil
+}
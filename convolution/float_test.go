@@ -0,0 +1,49 @@
+package convolution
+
+import (
+	"image"
+	"testing"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests------------------------------------
+func Test_ConvolveGrayFloat_SignedResponse(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(img.Pix, []uint8{10, 50, 20, 20})
+	kernel := Kernel{[][]float64{{-1}, {0}, {1}}, 3, 1}
+	res, err := ConvolveGrayFloat(img, &kernel, image.Point{X: 1, Y: 0}, padding.BorderReplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float32{40, 10, -30, 0}
+	for x, e := range expected {
+		if !utils.IsEqualFloat64(float64(res.At(x, 0, 0)), float64(e)) {
+			t.Errorf("Expected: %f - actual: %f at %d", e, res.At(x, 0, 0), x)
+		}
+	}
+}
+
+func Test_ConvolveGrayFloat_MatchesConvolveGray(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 40, 30))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 13)
+	}
+	for _, kernel := range []*Kernel{largeKernel(5, 3), largeKernel(15, 15)} {
+		anchor := image.Point{X: 2, Y: 1}
+		expected, err := ConvolveGray(img, kernel, anchor, padding.BorderReflect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ConvolveGrayFloat(img, kernel, anchor, padding.BorderReflect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := res.ToGray(utils.ConvertClamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareGrayImagesWithOffset(t, expected, actual, 1)
+	}
+}
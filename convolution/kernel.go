@@ -11,7 +11,7 @@ type Matrix interface {
 	At(x, y int) float64
 }
 
-// Kernel is a 2 dimensional matrix used mainly for convolution.
+// Kernel is a 2 dimensional matrix used mainly for convolution. The values are indexed as Content[x][y].
 type Kernel struct {
 	Content [][]float64
 	Width   int
@@ -23,9 +23,9 @@ func NewKernel(width int, height int) (*Kernel, error) {
 	if width < 0 || height < 0 {
 		return nil, errors.New("negative kernel size")
 	}
-	m := make([][]float64, height)
+	m := make([][]float64, width)
 	for i := range m {
-		m[i] = make([]float64, width)
+		m[i] = make([]float64, height)
 	}
 	return &Kernel{Content: m, Width: width, Height: height}, nil
 }
@@ -48,8 +48,8 @@ func (k *Kernel) Size() image.Point {
 // AbSum returns the sum of every absolute value from a kernel.
 func (k *Kernel) AbSum() float64 {
 	var sum float64
-	for x := 0; x < k.Height; x++ {
-		for y := 0; y < k.Width; y++ {
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
 			sum += math.Abs(k.At(x, y))
 		}
 	}
@@ -64,10 +64,53 @@ func (k *Kernel) Normalize() *Kernel {
 		sum = 1
 
 	}
-	for x := 0; x < k.Height; x++ {
-		for y := 0; y < k.Width; y++ {
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
 			normalized.Set(x, y, k.At(x, y)/sum)
 		}
 	}
 	return normalized
 }
+
+// separableEpsilon is the relative tolerance used when checking whether a kernel is separable.
+const separableEpsilon = 1e-9
+
+// Separate checks if the kernel is separable (its rank is 1), in which case it can be written as the outer product of
+// a horizontal and a vertical 1D kernel: k(x, y) = kernelX[x] * kernelY[y]. Returns the two 1D kernels and true if the
+// kernel is separable, otherwise false. The 1D kernels can be used with ConvolveSeparableGray and
+// ConvolveSeparableRGBA.
+func (k *Kernel) Separate() ([]float64, []float64, bool) {
+	if k.Width <= 0 || k.Height <= 0 {
+		return nil, nil, false
+	}
+	// pivot on the value with the largest magnitude for numerical stability
+	px, py := 0, 0
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
+			if math.Abs(k.At(x, y)) > math.Abs(k.At(px, py)) {
+				px, py = x, y
+			}
+		}
+	}
+	pivot := k.At(px, py)
+	kernelX := make([]float64, k.Width)
+	kernelY := make([]float64, k.Height)
+	if pivot == 0 {
+		return kernelX, kernelY, true
+	}
+	for x := 0; x < k.Width; x++ {
+		kernelX[x] = k.At(x, py)
+	}
+	for y := 0; y < k.Height; y++ {
+		kernelY[y] = k.At(px, y) / pivot
+	}
+	tolerance := separableEpsilon * math.Abs(pivot)
+	for x := 0; x < k.Width; x++ {
+		for y := 0; y < k.Height; y++ {
+			if math.Abs(k.At(x, y)-kernelX[x]*kernelY[y]) > tolerance {
+				return nil, nil, false
+			}
+		}
+	}
+	return kernelX, kernelY, true
+}
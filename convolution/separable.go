@@ -0,0 +1,131 @@
+package convolution
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ConvolveSeparableGray applies a separable convolution to a grayscale image. The 2D kernel is given by its horizontal
+// and vertical 1D components, kernel(x, y) = kernelX[x] * kernelY[y], and it is applied as a horizontal pass followed
+// by a vertical pass. This costs len(kernelX) + len(kernelY) operations per pixel instead of
+// len(kernelX) * len(kernelY). The anchor has the same meaning as for ConvolveGray.
+// Example of usage:
+//
+//	k := []float64{0.25, 0.5, 0.25}
+//	res, err := convolution.ConvolveSeparableGray(img, k, k, {1, 1}, BorderReflect)
+func ConvolveSeparableGray(img *image.Gray, kernelX []float64, kernelY []float64, anchor image.Point,
+	border padding.Border) (*image.Gray, error) {
+	return ConvolveSeparableGrayCtx(context.Background(), img, kernelX, kernelY, anchor, border, nil)
+}
+
+// ConvolveSeparableGrayCtx is ConvolveSeparableGray which can be aborted using ctx. The context is checked between the
+// rows of both passes, ctx.Err() is returned if it was cancelled.
+func ConvolveSeparableGrayCtx(ctx context.Context, img *image.Gray, kernelX []float64, kernelY []float64,
+	anchor image.Point, border padding.Border, opts *utils.Options) (*image.Gray, error) {
+	if len(kernelX) == 0 || len(kernelY) == 0 {
+		return nil, errors.New("empty kernel")
+	}
+	sampler, err := newGraySampler(img, image.Point{X: len(kernelX), Y: len(kernelY)}, anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	originalSize := img.Bounds().Size()
+	paddedHeight := originalSize.Y + len(kernelY) - 1
+
+	// horizontal pass, every row of the padded area is needed by the vertical pass
+	horizontal := make([]float64, originalSize.X*paddedHeight)
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), image.Point{X: originalSize.X, Y: paddedHeight},
+		func(x, y int) {
+			sum := 0.0
+			for kx, k := range kernelX {
+				sum += float64(sampler.GrayAt(x+kx-anchor.X, y-anchor.Y).Y) * k
+			}
+			horizontal[y*originalSize.X+x] = sum
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	resultImage := image.NewGray(image.Rect(0, 0, originalSize.X, originalSize.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), originalSize, func(x, y int) {
+		sum := 0.0
+		for ky, k := range kernelY {
+			sum += horizontal[(y+ky)*originalSize.X+x] * k
+		}
+		sum = utils.ClampF64(sum, utils.MinUint8, float64(utils.MaxUint8))
+		resultImage.Pix[y*resultImage.Stride+x] = uint8(sum)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resultImage, nil
+}
+
+// ConvolveSeparableRGBA applies a separable convolution to an RGBA image. See ConvolveSeparableGray. The alpha channel
+// is kept unchanged, as for ConvolveRGBA.
+// Example of usage:
+//
+//	k := []float64{0.25, 0.5, 0.25}
+//	res, err := convolution.ConvolveSeparableRGBA(img, k, k, {1, 1}, BorderReflect)
+func ConvolveSeparableRGBA(img *image.RGBA, kernelX []float64, kernelY []float64, anchor image.Point,
+	border padding.Border) (*image.RGBA, error) {
+	return ConvolveSeparableRGBACtx(context.Background(), img, kernelX, kernelY, anchor, border, nil)
+}
+
+// ConvolveSeparableRGBACtx is ConvolveSeparableRGBA which can be aborted using ctx. The context is checked between the
+// rows of both passes, ctx.Err() is returned if it was cancelled.
+func ConvolveSeparableRGBACtx(ctx context.Context, img *image.RGBA, kernelX []float64, kernelY []float64,
+	anchor image.Point, border padding.Border, opts *utils.Options) (*image.RGBA, error) {
+	if len(kernelX) == 0 || len(kernelY) == 0 {
+		return nil, errors.New("empty kernel")
+	}
+	sampler, err := newRGBASampler(img, image.Point{X: len(kernelX), Y: len(kernelY)}, anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	originalSize := img.Bounds().Size()
+	offset := img.Bounds().Min
+	paddedHeight := originalSize.Y + len(kernelY) - 1
+
+	// horizontal pass, storing the R, G and B sums for every position
+	horizontal := make([]float64, 3*originalSize.X*paddedHeight)
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), image.Point{X: originalSize.X, Y: paddedHeight},
+		func(x, y int) {
+			sumR, sumG, sumB := 0.0, 0.0, 0.0
+			for kx, k := range kernelX {
+				pixel := sampler.RGBAAt(x+kx-anchor.X, y-anchor.Y)
+				sumR += float64(pixel.R) * k
+				sumG += float64(pixel.G) * k
+				sumB += float64(pixel.B) * k
+			}
+			i := 3 * (y*originalSize.X + x)
+			horizontal[i], horizontal[i+1], horizontal[i+2] = sumR, sumG, sumB
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	resultImage := image.NewRGBA(image.Rect(0, 0, originalSize.X, originalSize.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), originalSize, func(x, y int) {
+		sumR, sumG, sumB := 0.0, 0.0, 0.0
+		for ky, k := range kernelY {
+			i := 3 * ((y+ky)*originalSize.X + x)
+			sumR += horizontal[i] * k
+			sumG += horizontal[i+1] * k
+			sumB += horizontal[i+2] * k
+		}
+		i := y*resultImage.Stride + 4*x
+		resultImage.Pix[i] = uint8(utils.ClampF64(sumR, utils.MinUint8, float64(utils.MaxUint8)))
+		resultImage.Pix[i+1] = uint8(utils.ClampF64(sumG, utils.MinUint8, float64(utils.MaxUint8)))
+		resultImage.Pix[i+2] = uint8(utils.ClampF64(sumB, utils.MinUint8, float64(utils.MaxUint8)))
+		resultImage.Pix[i+3] = img.RGBAAt(x+offset.X, y+offset.Y).A
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resultImage, nil
+}
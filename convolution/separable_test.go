@@ -0,0 +1,101 @@
+package convolution
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests------------------------------------
+func outerProduct(kernelX []float64, kernelY []float64) *Kernel {
+	kernel, _ := NewKernel(len(kernelX), len(kernelY))
+	for x, kx := range kernelX {
+		for y, ky := range kernelY {
+			kernel.Set(x, y, kx*ky)
+		}
+	}
+	return kernel
+}
+
+func Test_Kernel_Separate(t *testing.T) {
+	kernelX := []float64{1, 2, 3, 2, 1}
+	kernelY := []float64{-1, 0, 1}
+	kx, ky, ok := outerProduct(kernelX, kernelY).Separate()
+	if !ok {
+		t.Fatal("Expected the kernel to be separable")
+	}
+	for x := range kernelX {
+		for y := range kernelY {
+			if !utils.IsEqualFloat64(kx[x]*ky[y], kernelX[x]*kernelY[y]) {
+				t.Errorf("Expected: %f - actual: %f at %d, %d", kernelX[x]*kernelY[y], kx[x]*ky[y], x, y)
+			}
+		}
+	}
+
+	laplacian := Kernel{[][]float64{
+		{0, 1, 0},
+		{1, -4, 1},
+		{0, 1, 0},
+	}, 3, 3}
+	if _, _, ok := laplacian.Separate(); ok {
+		t.Error("Expected the Laplacian kernel not to be separable")
+	}
+}
+
+func Test_ConvolveSeparableGray_MatchesConvolveGray(t *testing.T) {
+	img, err := imgio.ImreadGray("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	cropped := img.SubImage(image.Rect(50, 60, 250, 200)).(*image.Gray)
+	kernelX := []float64{0.1, 0.2, 0.4, 0.2, 0.1}
+	kernelY := []float64{0.25, 0.5, 0.25}
+	anchor := image.Point{X: 1, Y: 2}
+	ctx := context.Background()
+	for _, opts := range []*utils.Options{nil, {Fill: color.White}} {
+		for _, border := range []padding.Border{padding.BorderConstant, padding.BorderReplicate, padding.BorderReflect,
+			padding.BorderWrap} {
+			expected, err := ConvolveGrayCtx(ctx, cropped, outerProduct(kernelX, kernelY), anchor, border, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := ConvolveSeparableGrayCtx(ctx, cropped, kernelX, kernelY, anchor, border, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			utils.CompareGrayImagesWithOffset(t, expected, actual, 1)
+		}
+	}
+}
+
+func Test_ConvolveSeparableRGBA_MatchesConvolveRGBA(t *testing.T) {
+	img, err := imgio.ImreadRGBA("../res/girl.jpg")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	cropped := img.SubImage(image.Rect(100, 100, 250, 220)).(*image.RGBA)
+	kernelX := []float64{0.25, 0.5, 0.25}
+	kernelY := []float64{0.1, 0.2, 0.4, 0.2, 0.1}
+	anchor := image.Point{X: 1, Y: 2}
+	expected, err := ConvolveRGBA(cropped, outerProduct(kernelX, kernelY), anchor, padding.BorderReflect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ConvolveSeparableRGBA(cropped, kernelX, kernelY, anchor, padding.BorderReflect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareRGBAImagesWithOffset(t, expected, actual, 1)
+}
+
+func Test_ConvolveSeparableGray_EmptyKernel(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 3))
+	if _, err := ConvolveSeparableGray(img, nil, []float64{1}, image.Point{}, padding.BorderConstant); err == nil {
+		t.Error("Expected an error for an empty kernel")
+	}
+}
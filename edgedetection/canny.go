@@ -1,51 +1,128 @@
 package edgedetection
 
 import (
+	"context"
 	"errors"
 	"image"
-	"image/color"
 	"math"
 
 	"github.com/ernyoke/imger/blur"
 	"github.com/ernyoke/imger/grayscale"
+	"github.com/ernyoke/imger/histogram"
 	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/threshold"
 	"github.com/ernyoke/imger/utils"
 )
 
+// CannyOptions holds the settings of the Canny edge detector.
+type CannyOptions struct {
+	// KernelSize is the radius of the Gaussian kernel used to smooth the image. If it is 0, the radius is derived from
+	// Sigma as ceil(3 * Sigma).
+	KernelSize uint
+	// Sigma is the standard deviation of the Gaussian kernel. If it is 0, 1 is used.
+	Sigma float64
+	// Operator is the gradient operator, OperatorSobel by default.
+	Operator Operator
+	// Norm is the norm used for the magnitude of the gradient, NormL2 by default.
+	Norm Norm
+	// Border is the border used for reading the pixels outside of the image, BorderConstant by default. BorderReflect
+	// avoids the false edges along the sides of the image.
+	Border padding.Border
+	// Options selects the executor processing the rows of the image, see utils.Options.
+	utils.Options
+}
+
+// CannyThresholds is an enum type for the ways of deriving the hysteresis thresholds automatically
+type CannyThresholds int
+
+const (
+	// CannyThresholdsMedian - the thresholds are placed 33% below and above the median intensity of the image
+	CannyThresholdsMedian CannyThresholds = iota
+	// CannyThresholdsOtsu - the upper threshold is the Otsu level of the image, the lower threshold is half of it
+	CannyThresholdsOtsu
+)
+
+// cannyMedianSigma is the relative distance of the thresholds from the median for CannyThresholdsMedian.
+const cannyMedianSigma = 0.33
+
 // CannyGray computes the edges of a given grayscale image using the Canny edge detection algorithm. The returned image
-// is a grayscale image represented on 8 bits.
+// is a grayscale image represented on 8 bits. The image is smoothed using a Gaussian kernel with the given radius
+// (kernelSize) and sigma 1. Use CannyGrayWithOptions for choosing sigma, the gradient operator and the norm.
 func CannyGray(img *image.Gray, lower float64, upper float64, kernelSize uint) (*image.Gray, error) {
+	return CannyGrayCtx(context.Background(), img, lower, upper, kernelSize)
+}
+
+// CannyGrayCtx is CannyGray which can be aborted using ctx. The context is checked between the stages of the
+// algorithm and between the rows of the convolutions, ctx.Err() is returned if it was cancelled.
+func CannyGrayCtx(ctx context.Context, img *image.Gray, lower float64, upper float64,
+	kernelSize uint) (*image.Gray, error) {
+	return CannyGrayWithOptionsCtx(ctx, img, lower, upper, &CannyOptions{KernelSize: kernelSize, Sigma: 1})
+}
+
+// CannyGrayWithOptions computes the edges of a given grayscale image using the Canny edge detection algorithm:
+//   - the image is smoothed using a Gaussian kernel,
+//   - the signed gradient is computed using the chosen operator,
+//   - the pixels which are not a local maximum of the magnitude along the gradient direction (rounded to one of four
+//     directions) are suppressed,
+//   - the remaining pixels with a magnitude above upper are edges, together with the pixels with a magnitude above
+//     lower which are connected to them (8-connectivity).
+//
+// The pixels outside of the image are read according to opts.Border. A nil opts uses the default options. The edges
+// are marked as white on a black background.
+// Example of usage:
+//
+//	edges, err := edgedetection.CannyGrayWithOptions(img, 50, 150, &edgedetection.CannyOptions{Sigma: 1.4,
+//		Border: padding.BorderReflect})
+func CannyGrayWithOptions(img *image.Gray, lower float64, upper float64, opts *CannyOptions) (*image.Gray, error) {
+	return CannyGrayWithOptionsCtx(context.Background(), img, lower, upper, opts)
+}
+
+// CannyGrayWithOptionsCtx is CannyGrayWithOptions which can be aborted using ctx. ctx.Err() is returned if the context
+// was cancelled.
+func CannyGrayWithOptionsCtx(ctx context.Context, img *image.Gray, lower float64, upper float64,
+	opts *CannyOptions) (*image.Gray, error) {
+	if lower < 0 || lower > upper {
+		return nil, errors.New("lower threshold must be between 0 and the upper threshold")
+	}
+	if opts == nil {
+		opts = &CannyOptions{}
+	}
+	sigma := opts.Sigma
+	if sigma == 0 {
+		sigma = 1
+	}
+	if sigma < 0 {
+		return nil, errors.New("sigma must be bigger then 0")
+	}
+	radius := float64(opts.KernelSize)
+	if radius == 0 {
+		radius = math.Ceil(3 * sigma)
+	}
 
 	// blur the image using Gaussian filter
-	blurred, err := blur.GaussianBlurGray(img, float64(kernelSize), 1, padding.BorderConstant)
+	blurred, err := blur.GaussianBlurGrayCtx(ctx, img, radius, sigma, opts.Border, &opts.Options)
 	if err != nil {
 		return nil, err
 	}
 
-	// get vertical and horizontal edges using Sobel filter
-	vertical, err := VerticalSobelGray(blurred, padding.BorderConstant)
+	// calculate the signed gradient and its magnitude for each pixel
+	gradient, err := GradientGrayCtx(ctx, blurred, opts.Operator, opts.Border, &opts.Options)
 	if err != nil {
 		return nil, err
 	}
-	horizontal, err := HorizontalSobelGray(blurred, padding.BorderConstant)
-	if err != nil {
+	magnitude := gradient.Magnitude(opts.Norm)
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	// calculate the gradient values and orientation angles for each pixel
-	g, theta, err := gradientAndOrientation(vertical, horizontal)
-	if err != nil {
+	// "thin" the edges using non-max suppression procedure
+	candidates := nonMaxSuppression(gradient, magnitude, float32(lower), float32(upper))
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	// "thin" the edges using non-max suppression procedure
-	thinEdges := nonMaxSuppression(blurred, g, theta)
-
 	// hysteresis
-	hist := threshold(thinEdges, g, lower, upper)
-	// _ = threshold(thinEdges, g, lower, upper)
-
-	return hist, nil
+	return hysteresis(candidates, magnitude.Width, magnitude.Height), nil
 }
 
 // CannyRGBA computes the edges of a given RGBA image using the Canny edge detection algorithm. The returned image is a
@@ -54,115 +131,173 @@ func CannyRGBA(img *image.RGBA, lower float64, upper float64, kernelSize uint) (
 	return CannyGray(grayscale.Grayscale(img), lower, upper, kernelSize)
 }
 
-func gradientAndOrientation(vertical *image.Gray, horizontal *image.Gray) ([][]float64, [][]float64, error) {
-	size := vertical.Bounds().Size()
-	theta := make([][]float64, size.X)
-	g := make([][]float64, size.X)
-	for x := 0; x < size.X; x++ {
-		theta[x] = make([]float64, size.Y)
-		g[x] = make([]float64, size.Y)
-		err := errors.New("none")
-		for y := 0; y < size.Y; y++ {
-			px := float64(vertical.GrayAt(x, y).Y)
-			py := float64(horizontal.GrayAt(x, y).Y)
-			g[x][y] = math.Hypot(px, py)
-			theta[x][y], err = orientation(math.Atan2(float64(vertical.GrayAt(x, y).Y), float64(horizontal.GrayAt(x, y).Y)))
-			if err != nil {
-				return nil, nil, err
-			}
-		}
-	}
-	return g, theta, nil
+// CannyRGBACtx is CannyRGBA which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func CannyRGBACtx(ctx context.Context, img *image.RGBA, lower float64, upper float64,
+	kernelSize uint) (*image.Gray, error) {
+	return CannyGrayCtx(ctx, grayscale.Grayscale(img), lower, upper, kernelSize)
 }
 
-func isBetween(val float64, lowerBound float64, upperBound float64) bool {
-	return val >= lowerBound && val < upperBound
+// CannyRGBAWithOptions computes the edges of the grayscale version of an RGBA image. See CannyGrayWithOptions.
+func CannyRGBAWithOptions(img *image.RGBA, lower float64, upper float64, opts *CannyOptions) (*image.Gray, error) {
+	return CannyRGBAWithOptionsCtx(context.Background(), img, lower, upper, opts)
 }
 
-func orientation(x float64) (float64, error) {
-	angle := 180 * x / math.Pi
-	if isBetween(angle, 0, 22.5) || isBetween(angle, -180, -157.5) {
-		return 0, nil
-	}
-	if isBetween(angle, 157.5, 180) || isBetween(angle, -22.5, 0) {
-		return 0, nil
-	}
-	if isBetween(angle, 22.5, 67.5) || isBetween(angle, -157.5, -112.5) {
-		return 45, nil
-	}
-	if isBetween(angle, 67.5, 112.5) || isBetween(angle, -112.5, -67.5) {
-		return 90, nil
-	}
-	if isBetween(angle, 112.5, 157.5) || isBetween(angle, -67.5, -22.5) {
-		return 135, nil
+// CannyRGBAWithOptionsCtx is CannyRGBAWithOptions which can be aborted using ctx. ctx.Err() is returned if the context
+// was cancelled.
+func CannyRGBAWithOptionsCtx(ctx context.Context, img *image.RGBA, lower float64, upper float64,
+	opts *CannyOptions) (*image.Gray, error) {
+	return CannyGrayWithOptionsCtx(ctx, grayscale.Grayscale(img), lower, upper, opts)
+}
+
+// CannyAutoGray computes the edges of a given grayscale image using the Canny edge detection algorithm, with the
+// thresholds derived from the image using the given method. See CannyAutoThresholds and CannyGrayWithOptions.
+// Example of usage:
+//
+//	edges, err := edgedetection.CannyAutoGray(img, edgedetection.CannyThresholdsMedian, nil)
+func CannyAutoGray(img *image.Gray, method CannyThresholds, opts *CannyOptions) (*image.Gray, error) {
+	return CannyAutoGrayCtx(context.Background(), img, method, opts)
+}
+
+// CannyAutoGrayCtx is CannyAutoGray which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func CannyAutoGrayCtx(ctx context.Context, img *image.Gray, method CannyThresholds,
+	opts *CannyOptions) (*image.Gray, error) {
+	lower, upper, err := CannyAutoThresholds(img, method)
+	if err != nil {
+		return nil, err
 	}
-	return 0, errors.New("invalid angle")
+	return CannyGrayWithOptionsCtx(ctx, img, lower, upper, opts)
 }
 
-func isBiggerThenNeighbours(val float64, neighbour1 float64, neighbour2 float64) bool {
-	return val > neighbour1 && val > neighbour2
+// CannyAutoRGBA computes the edges of the grayscale version of an RGBA image. See CannyAutoGray.
+func CannyAutoRGBA(img *image.RGBA, method CannyThresholds, opts *CannyOptions) (*image.Gray, error) {
+	return CannyAutoRGBACtx(context.Background(), img, method, opts)
 }
 
-func nonMaxSuppression(img *image.Gray, g [][]float64, theta [][]float64) *image.Gray {
-	size := img.Bounds().Size()
-	thinEdges := image.NewGray(image.Rect(0, 0, size.X, size.Y))
-	utils.ForEachPixel(img, func(_ color.Color, x, y int) {
-		isLocalMax := false
-		if x > 0 && x < size.X-1 && y > 0 && y < size.Y-1 {
-			switch theta[x][y] {
-			case 45:
-				if isBiggerThenNeighbours(g[x][y], g[x+1][y-1], g[x-1][y+1]) {
-					isLocalMax = true
-				}
-			case 90:
-				if isBiggerThenNeighbours(g[x][y], g[x+1][y], g[x-1][y]) {
-					isLocalMax = true
-				}
-			case 135:
-				if isBiggerThenNeighbours(g[x][y], g[x-1][y-1], g[x+1][y+1]) {
-					isLocalMax = true
-				}
-			case 0:
-				if isBiggerThenNeighbours(g[x][y], g[x][y+1], g[x][y-1]) {
-					isLocalMax = true
+// CannyAutoRGBACtx is CannyAutoRGBA which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func CannyAutoRGBACtx(ctx context.Context, img *image.RGBA, method CannyThresholds,
+	opts *CannyOptions) (*image.Gray, error) {
+	return CannyAutoGrayCtx(ctx, grayscale.Grayscale(img), method, opts)
+}
+
+// CannyAutoThresholds derives the lower and upper thresholds of the Canny edge detector from the intensities of an
+// image:
+//   - CannyThresholdsMedian: lower = 0.67 * median, upper = 1.33 * median, limited to [0, 255],
+//   - CannyThresholdsOtsu: upper = Otsu level, lower = upper / 2.
+func CannyAutoThresholds(img *image.Gray, method CannyThresholds) (float64, float64, error) {
+	switch method {
+	case CannyThresholdsMedian:
+		m := float64(medianGray(img))
+		lower := math.Max(0, (1-cannyMedianSigma)*m)
+		upper := math.Min(float64(utils.MaxUint8), (1+cannyMedianSigma)*m)
+		return lower, upper, nil
+	case CannyThresholdsOtsu:
+		upper := float64(threshold.OtsuLevel(img))
+		return upper / 2, upper, nil
+	}
+	return 0, 0, errors.New("unknown threshold method")
+}
+
+// -------------------------------------------------------------------------------------------------------
+const (
+	cannyNone = iota
+	cannyWeak
+	cannyStrong
+)
+
+// tan(22.5) and tan(67.5), the limits between the horizontal, diagonal and vertical gradient directions
+var (
+	tan22 = math.Tan(math.Pi / 8)
+	tan67 = math.Tan(3 * math.Pi / 8)
+)
+
+// nonMaxSuppression keeps the pixels whose magnitude is a local maximum along the gradient direction and classifies
+// them as weak (above lower) or strong (above upper) edge candidates. The result is indexed as y*width+x.
+func nonMaxSuppression(gradient *Gradient, magnitude *utils.Float32Image, lower float32, upper float32) []uint8 {
+	width, height := magnitude.Width, magnitude.Height
+	at := func(x, y int) float32 {
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return 0
+		}
+		return magnitude.Pix[y*width+x]
+	}
+	candidates := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			m := magnitude.Pix[i]
+			if m <= lower {
+				continue
+			}
+			dx, dy := float64(gradient.DX.Pix[i]), float64(gradient.DY.Pix[i])
+			ax, ay := math.Abs(dx), math.Abs(dy)
+			var n1, n2 float32
+			switch {
+			case ay <= ax*tan22:
+				n1, n2 = at(x-1, y), at(x+1, y)
+			case ay >= ax*tan67:
+				n1, n2 = at(x, y-1), at(x, y+1)
+			case dx*dy > 0:
+				n1, n2 = at(x-1, y-1), at(x+1, y+1)
+			default:
+				n1, n2 = at(x+1, y-1), at(x-1, y+1)
+			}
+			// ties are broken towards the first neighbour, so a plateau still produces a single pixel wide edge
+			if m > n1 && m >= n2 {
+				if m > upper {
+					candidates[i] = cannyStrong
+				} else {
+					candidates[i] = cannyWeak
 				}
 			}
 		}
-		if isLocalMax {
-			thinEdges.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
-		}
-	})
-	return thinEdges
+	}
+	return candidates
 }
 
-func threshold(img *image.Gray, g [][]float64, lowerBound float64, upperBound float64) *image.Gray {
-	size := img.Bounds().Size()
-	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
-	utils.ForEachGrayPixel(img, func(p color.Gray, x, y int) {
-		if p.Y == utils.MaxUint8 {
-			if g[x][y] < lowerBound {
-				res.SetGray(x, y, color.Gray{Y: utils.MinUint8})
-			}
-			if g[x][y] > upperBound {
-				res.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
-			}
+// hysteresis marks the strong candidates and every weak candidate which is 8-connected to a strong one as edges. The
+// connected pixels are tracked using an explicit stack, so the result does not depend on the scanning order.
+func hysteresis(candidates []uint8, width int, height int) *image.Gray {
+	res := image.NewGray(image.Rect(0, 0, width, height))
+	var stack []int
+	for i, c := range candidates {
+		if c == cannyStrong {
+			res.Pix[i] = utils.MaxUint8
+			stack = append(stack, i)
 		}
-	})
-	utils.ForEachGrayPixel(img, func(p color.Gray, x, y int) {
-		if p.Y == utils.MaxUint8 && x > 0 && x < size.X-1 && y > 0 && y < size.Y-1 {
-			if g[x][y] >= lowerBound && g[x][y] <= upperBound {
-				if checkNeighbours(x, y, res) {
-					res.SetGray(x, y, color.Gray{Y: utils.MinUint8})
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := i%width, i/width
+		for ny := y - 1; ny <= y+1; ny++ {
+			for nx := x - 1; nx <= x+1; nx++ {
+				if nx < 0 || ny < 0 || nx >= width || ny >= height {
+					continue
+				}
+				j := ny*width + nx
+				if candidates[j] == cannyWeak && res.Pix[j] == 0 {
+					res.Pix[j] = utils.MaxUint8
+					stack = append(stack, j)
 				}
 			}
 		}
-	})
+	}
 	return res
 }
 
-func checkNeighbours(x, y int, img *image.Gray) bool {
-	return img.GrayAt(x-1, y-1).Y == utils.MaxUint8 || img.GrayAt(x-1, y).Y == utils.MaxUint8 ||
-		img.GrayAt(x-1, y+1).Y == utils.MaxUint8 || img.GrayAt(x, y-1).Y == utils.MaxUint8 ||
-		img.GrayAt(x, y+1).Y == utils.MaxUint8 || img.GrayAt(x+1, y-1).Y == utils.MaxUint8 ||
-		img.GrayAt(x+1, y).Y == utils.MaxUint8 || img.GrayAt(x+1, y+1).Y == utils.MaxUint8
+// medianGray returns the median intensity of a grayscale image.
+func medianGray(img *image.Gray) uint8 {
+	hist := histogram.HistogramGray(img)
+	size := img.Bounds().Size()
+	half := uint64(size.X*size.Y) / 2
+	var sum uint64
+	for v, count := range hist {
+		sum += count
+		if sum > half {
+			return uint8(v)
+		}
+	}
+	return utils.MaxUint8
 }
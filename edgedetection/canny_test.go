@@ -1,12 +1,171 @@
 package edgedetection
 
 import (
+	"context"
 	"image"
+	"math"
 	"testing"
 
 	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/padding"
 )
 
+// ---------------------------------Unit tests------------------------------------
+func Test_CannyGrayCtx_Cancelled(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 16, 16))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CannyGrayCtx(ctx, gray, 15, 45, 5); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+}
+
+// stepGray returns an image with a vertical edge: the columns [from, to) are bright, the others are dark.
+func stepGray(width, height, from, to int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := from; x < to; x++ {
+			img.Pix[y*img.Stride+x] = 200
+		}
+	}
+	return img
+}
+
+func edgeColumns(img *image.Gray, y int) []int {
+	var columns []int
+	for x := 0; x < img.Bounds().Dx(); x++ {
+		if img.GrayAt(x, y).Y == 255 {
+			columns = append(columns, x)
+		}
+	}
+	return columns
+}
+
+func Test_CannyGray_BothPolarities(t *testing.T) {
+	// a bright band: rising edge between columns 5 and 6, falling edge between columns 13 and 14
+	edges, err := CannyGray(stepGray(20, 12, 6, 14), 20, 60, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the rows close to the top and the bottom also have the edges of the black BorderConstant
+	for y := 4; y < 8; y++ {
+		columns := edgeColumns(edges, y)
+		if len(columns) != 2 || columns[0] < 5 || columns[0] > 6 || columns[1] < 13 || columns[1] > 14 {
+			t.Fatalf("Expected one thin edge on both sides of the band in row %d - actual columns: %v", y, columns)
+		}
+	}
+}
+
+func Test_CannyGrayWithOptions_Sigma(t *testing.T) {
+	img := stepGray(24, 8, 12, 24)
+	reflect := padding.BorderReflect
+	for _, opts := range []*CannyOptions{{Border: reflect}, {Sigma: 2, Border: reflect},
+		{Sigma: 0.5, KernelSize: 1, Border: reflect}, {Operator: OperatorScharr, Norm: NormL1, Border: reflect}} {
+		edges, err := CannyGrayWithOptions(img, 20, 60, opts)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for y := 0; y < 8; y++ {
+			if columns := edgeColumns(edges, y); len(columns) != 1 {
+				t.Fatalf("Expected a single edge pixel in row %d with options %+v - actual columns: %v", y, opts,
+					columns)
+			}
+		}
+	}
+	if _, err := CannyGrayWithOptions(img, 20, 60, &CannyOptions{Sigma: -1}); err == nil {
+		t.Error("Expected error for negative sigma")
+	}
+}
+
+func Test_CannyGrayWithOptions_Border(t *testing.T) {
+	img := stepGray(10, 10, 0, 10)
+	constant, err := CannyGrayWithOptions(img, 20, 60, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if columns := edgeColumns(constant, 5); len(columns) != 2 || columns[0] != 0 || columns[1] != 9 {
+		t.Errorf("Expected the edges of the black border at the sides by default - actual columns: %v", columns)
+	}
+	reflected, err := CannyGrayWithOptions(img, 20, 60, &CannyOptions{Border: padding.BorderReflect})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for y := 0; y < 10; y++ {
+		if columns := edgeColumns(reflected, y); len(columns) != 0 {
+			t.Errorf("Expected no edges with BorderReflect in row %d - actual columns: %v", y, columns)
+		}
+	}
+}
+
+func Test_CannyRGBACtx_Cancelled(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CannyRGBAWithOptionsCtx(ctx, rgba, 15, 45, nil); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+	if _, err := CannyAutoRGBACtx(ctx, rgba, CannyThresholdsOtsu, nil); err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+}
+
+func Test_CannyGray_InvalidThresholds(t *testing.T) {
+	img := stepGray(8, 8, 4, 8)
+	if _, err := CannyGray(img, 60, 20, 1); err == nil {
+		t.Error("Expected error for lower threshold bigger than upper threshold")
+	}
+	if _, err := CannyGray(img, -1, 20, 1); err == nil {
+		t.Error("Expected error for negative lower threshold")
+	}
+}
+
+func Test_Hysteresis_TracksConnectedWeakEdges(t *testing.T) {
+	const width, height = 12, 3
+	candidates := make([]uint8, width*height)
+	// a long diagonal-ish chain of weak pixels starting from a strong one
+	candidates[0] = cannyStrong
+	for x := 1; x < 10; x++ {
+		candidates[(x%2)*width+x] = cannyWeak
+	}
+	// an isolated weak pixel
+	candidates[2*width+11] = cannyWeak
+	res := hysteresis(candidates, width, height)
+	for x := 1; x < 10; x++ {
+		if res.Pix[(x%2)*width+x] != 255 {
+			t.Errorf("Expected weak pixel %d of the chain to be kept", x)
+		}
+	}
+	if res.Pix[2*width+11] != 0 {
+		t.Error("Expected isolated weak pixel to be removed")
+	}
+}
+
+func Test_CannyAutoThresholds(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 1))
+	for x := 0; x < 10; x++ {
+		img.Pix[x] = uint8(10 + x*20)
+	}
+	lower, upper, err := CannyAutoThresholds(img, CannyThresholdsMedian)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// median: 110
+	if math.Abs(lower-73.7) > 1e-9 || math.Abs(upper-146.3) > 1e-9 {
+		t.Errorf("Expected median thresholds 73.7, 146.3 - actual: %f, %f", lower, upper)
+	}
+	lower, upper, err = CannyAutoThresholds(img, CannyThresholdsOtsu)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Otsu level: 90
+	if lower != 45 || upper != 90 {
+		t.Errorf("Expected Otsu thresholds 45, 90 - actual: %f, %f", lower, upper)
+	}
+	if _, _, err := CannyAutoThresholds(img, CannyThresholds(42)); err == nil {
+		t.Error("Expected error for unknown method")
+	}
+}
+
 // -----------------------------Acceptance tests------------------------------------
 func setupTestCaseGray(t *testing.T) *image.Gray {
 	path := "../res/engine.png"
@@ -70,3 +229,12 @@ func Test_Acceptance_CannyRGBA_Cropped(t *testing.T) {
 	}
 	tearDownTestCase(t, cny, "../res/edge/cannyrgbaCropped.jpg")
 }
+
+func Test_Acceptance_CannyAutoGray(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	cny, err := CannyAutoGray(gray, CannyThresholdsMedian, &CannyOptions{Sigma: 1.4})
+	if err != nil {
+		t.Fatalf("Should not reach this point!")
+	}
+	tearDownTestCase(t, cny, "../res/edge/cannyAutoGray.jpg")
+}
@@ -0,0 +1,147 @@
+package edgedetection
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+
+	"github.com/ernyoke/imger/convolution"
+	"github.com/ernyoke/imger/grayscale"
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// Operator is an enum type for the supported gradient operators
+type Operator int
+
+const (
+	// OperatorSobel - derivative [-1 0 1] smoothed with [1 2 1] in the other direction
+	OperatorSobel Operator = iota
+	// OperatorScharr - derivative [-1 0 1] smoothed with [3 10 3], which is more rotation invariant than Sobel
+	OperatorScharr
+	// OperatorPrewitt - derivative [-1 0 1] smoothed with [1 1 1]
+	OperatorPrewitt
+)
+
+// Norm is an enum type for the ways of computing the magnitude of a gradient
+type Norm int
+
+const (
+	// NormL2 - sqrt(dx^2 + dy^2), the euclidean length of the gradient
+	NormL2 Norm = iota
+	// NormL1 - |dx| + |dy|, faster to compute approximation of the length of the gradient
+	NormL1
+)
+
+// Gradient holds the signed first derivatives of an image.
+type Gradient struct {
+	// DX is the derivative in the x direction, positive where the intensity increases to the right
+	DX *utils.Float32Image
+	// DY is the derivative in the y direction, positive where the intensity increases downwards
+	DY *utils.Float32Image
+}
+
+// SobelGradient computes the gradient of a grayscale image using the Sobel operator. Unlike HorizontalSobelGray and
+// VerticalSobelGray the derivatives are not clamped, so negative edges are kept.
+// Example of usage:
+//
+//	g, err := edgedetection.SobelGradient(img, padding.BorderReflect)
+//	magnitude := g.Magnitude(edgedetection.NormL2)
+//	orientation := g.Orientation()
+func SobelGradient(img *image.Gray, border padding.Border) (*Gradient, error) {
+	return GradientGray(img, OperatorSobel, border)
+}
+
+// ScharrGradient computes the gradient of a grayscale image using the Scharr operator. See SobelGradient.
+func ScharrGradient(img *image.Gray, border padding.Border) (*Gradient, error) {
+	return GradientGray(img, OperatorScharr, border)
+}
+
+// PrewittGradient computes the gradient of a grayscale image using the Prewitt operator. See SobelGradient.
+func PrewittGradient(img *image.Gray, border padding.Border) (*Gradient, error) {
+	return GradientGray(img, OperatorPrewitt, border)
+}
+
+// GradientGray computes the gradient of a grayscale image using the given 3x3 operator.
+// Example of usage:
+//
+//	g, err := edgedetection.GradientGray(img, edgedetection.OperatorScharr, padding.BorderReflect)
+func GradientGray(img *image.Gray, operator Operator, border padding.Border) (*Gradient, error) {
+	return GradientGrayCtx(context.Background(), img, operator, border, nil)
+}
+
+// GradientGrayCtx is GradientGray which can be aborted using ctx. ctx.Err() is returned if the context was cancelled.
+func GradientGrayCtx(ctx context.Context, img *image.Gray, operator Operator, border padding.Border,
+	opts *utils.Options) (*Gradient, error) {
+	kernelX, kernelY, err := gradientKernels(operator)
+	if err != nil {
+		return nil, err
+	}
+	anchor := image.Point{X: 1, Y: 1}
+	dx, err := convolution.ConvolveGrayFloatCtx(ctx, img, kernelX, anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	dy, err := convolution.ConvolveGrayFloatCtx(ctx, img, kernelY, anchor, border, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Gradient{DX: dx, DY: dy}, nil
+}
+
+// GradientRGBA computes the gradient of the grayscale version of an RGBA image. See GradientGray.
+func GradientRGBA(img *image.RGBA, operator Operator, border padding.Border) (*Gradient, error) {
+	return GradientGray(grayscale.Grayscale(img), operator, border)
+}
+
+// Magnitude returns the length of the gradient at every pixel, computed using the given norm.
+func (g *Gradient) Magnitude(norm Norm) *utils.Float32Image {
+	res, _ := utils.NewFloat32Image(g.DX.Width, g.DX.Height, 1)
+	for i := range res.Pix {
+		dx, dy := float64(g.DX.Pix[i]), float64(g.DY.Pix[i])
+		if norm == NormL1 {
+			res.Pix[i] = float32(math.Abs(dx) + math.Abs(dy))
+		} else {
+			res.Pix[i] = float32(math.Hypot(dx, dy))
+		}
+	}
+	return res
+}
+
+// Orientation returns the direction of the gradient at every pixel in radians, in the range [-Pi, Pi]. The angle is
+// measured from the x axis towards the y axis, so with the y axis pointing downwards Pi/2 points down.
+func (g *Gradient) Orientation() *utils.Float32Image {
+	res, _ := utils.NewFloat32Image(g.DX.Width, g.DX.Height, 1)
+	for i := range res.Pix {
+		res.Pix[i] = float32(math.Atan2(float64(g.DY.Pix[i]), float64(g.DX.Pix[i])))
+	}
+	return res
+}
+
+// -------------------------------------------------------------------------------------------------------
+// gradientKernels returns the kernels of the x and y derivatives of an operator. The kernels are indexed as
+// Content[x][y].
+func gradientKernels(operator Operator) (*convolution.Kernel, *convolution.Kernel, error) {
+	derivative := []float64{-1, 0, 1}
+	var smoothing []float64
+	switch operator {
+	case OperatorSobel:
+		smoothing = []float64{1, 2, 1}
+	case OperatorScharr:
+		smoothing = []float64{3, 10, 3}
+	case OperatorPrewitt:
+		smoothing = []float64{1, 1, 1}
+	default:
+		return nil, nil, errors.New("unknown gradient operator")
+	}
+	kernelX, _ := convolution.NewKernel(3, 3)
+	kernelY, _ := convolution.NewKernel(3, 3)
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			kernelX.Set(x, y, derivative[x]*smoothing[y])
+			kernelY.Set(x, y, smoothing[x]*derivative[y])
+		}
+	}
+	return kernelX, kernelY, nil
+}
@@ -0,0 +1,112 @@
+package edgedetection
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+// rampGray returns an image whose intensity increases by stepX to the right and by stepY downwards.
+func rampGray(width, height, stepX, stepY int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Pix[y*img.Stride+x] = uint8(100 + x*stepX + y*stepY)
+		}
+	}
+	return img
+}
+
+func Test_GradientGray_Ramp(t *testing.T) {
+	cases := []struct {
+		operator Operator
+		weight   float64
+	}{
+		{OperatorSobel, 4},
+		{OperatorScharr, 16},
+		{OperatorPrewitt, 3},
+	}
+	// interior of a ramp: central difference (2*step) times the sum of the smoothing weights
+	img := rampGray(5, 5, 3, -2)
+	for _, c := range cases {
+		g, err := GradientGray(img, c.operator, padding.BorderReplicate)
+		if err != nil {
+			t.Fatalf("operator %d: unexpected error: %v", c.operator, err)
+		}
+		for y := 1; y < 4; y++ {
+			for x := 1; x < 4; x++ {
+				if !utils.IsEqualFloat64(float64(g.DX.At(x, y, 0)), 6*c.weight) {
+					t.Errorf("operator %d: expected dx %f at (%d, %d), got %f", c.operator, 6*c.weight, x, y,
+						g.DX.At(x, y, 0))
+				}
+				if !utils.IsEqualFloat64(float64(g.DY.At(x, y, 0)), -4*c.weight) {
+					t.Errorf("operator %d: expected dy %f at (%d, %d), got %f", c.operator, -4*c.weight, x, y,
+						g.DY.At(x, y, 0))
+				}
+			}
+		}
+	}
+}
+
+func Test_SobelGradient_KeepsNegativeEdges(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 6, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			img.Pix[y*img.Stride+x] = 200
+		}
+	}
+	g, err := SobelGradient(img, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := g.DX.At(2, 1, 0); !utils.IsEqualFloat64(float64(v), -800) {
+		t.Errorf("expected dx -800, got %f", v)
+	}
+	if v := g.DY.At(2, 1, 0); !utils.IsEqualFloat64(float64(v), 0) {
+		t.Errorf("expected dy 0, got %f", v)
+	}
+	orientation := g.Orientation().At(2, 1, 0)
+	if !utils.IsEqualFloat64(math.Abs(float64(orientation)), float64(float32(math.Pi))) {
+		t.Errorf("expected orientation Pi, got %f", orientation)
+	}
+}
+
+func Test_Gradient_MagnitudeAndOrientation(t *testing.T) {
+	g, err := SobelGradient(rampGray(5, 5, 3, 4), padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// dx = 24, dy = 32
+	if v := g.Magnitude(NormL2).At(2, 2, 0); !utils.IsEqualFloat64(float64(v), 40) {
+		t.Errorf("expected L2 magnitude 40, got %f", v)
+	}
+	if v := g.Magnitude(NormL1).At(2, 2, 0); !utils.IsEqualFloat64(float64(v), 56) {
+		t.Errorf("expected L1 magnitude 56, got %f", v)
+	}
+	expected := float64(float32(math.Atan2(32, 24)))
+	if v := g.Orientation().At(2, 2, 0); !utils.IsEqualFloat64(float64(v), expected) {
+		t.Errorf("expected orientation %f, got %f", expected, v)
+	}
+}
+
+func Test_SobelGray_Magnitude(t *testing.T) {
+	res, err := SobelGray(rampGray(5, 5, 3, 4), padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := res.GrayAt(2, 2).Y; v != 40 {
+		t.Errorf("expected 40, got %d", v)
+	}
+}
+
+func Test_GradientGray_UnknownOperator(t *testing.T) {
+	_, err := GradientGray(rampGray(3, 3, 1, 1), Operator(42), padding.BorderReplicate)
+	if err == nil {
+		t.Error("expected error for unknown operator")
+	}
+}
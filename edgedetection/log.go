@@ -0,0 +1,170 @@
+package edgedetection
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/ernyoke/imger/convolution"
+	"github.com/ernyoke/imger/grayscale"
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// LoGGray applies the Laplacian of Gaussian filter to a grayscale image: the image is smoothed by a Gaussian with the
+// given sigma and the Laplacian of the result is computed, using a single kernel with the radius ceil(3 * sigma). The
+// response is signed and not clamped: it is zero on flat and linear areas and changes sign across the edges, see
+// ZeroCrossings. The pixels outside of the image are read as BorderReflect.
+// Example of usage:
+//
+//	response, err := edgedetection.LoGGray(img, 2)
+//	edges, err := edgedetection.ZeroCrossings(response, 1)
+func LoGGray(img *image.Gray, sigma float64) (*utils.Float32Image, error) {
+	if sigma <= 0 {
+		return nil, errors.New("sigma must be bigger then 0")
+	}
+	radius := int(math.Ceil(3 * sigma))
+	gaussian := gaussianKernel(radius, sigma)
+	kernel, _ := convolution.NewKernel(2*radius+1, 2*radius+1)
+	var sum float64
+	for x := 0; x < kernel.Width; x++ {
+		for y := 0; y < kernel.Height; y++ {
+			dx, dy := float64(x-radius), float64(y-radius)
+			value := gaussian.At(x, y) * (dx*dx + dy*dy - 2*sigma*sigma) / (sigma * sigma * sigma * sigma)
+			kernel.Set(x, y, value)
+			sum += value
+		}
+	}
+	// the truncated kernel does not sum to zero exactly, which would give a response on flat areas
+	mean := sum / float64(kernel.Width*kernel.Height)
+	for x := 0; x < kernel.Width; x++ {
+		for y := 0; y < kernel.Height; y++ {
+			kernel.Set(x, y, kernel.At(x, y)-mean)
+		}
+	}
+	return convolution.ConvolveGrayFloat(img, kernel, image.Point{X: radius, Y: radius}, padding.BorderReflect)
+}
+
+// LoGRGBA applies the Laplacian of Gaussian filter to the grayscale version of an RGBA image. See LoGGray.
+func LoGRGBA(img *image.RGBA, sigma float64) (*utils.Float32Image, error) {
+	return LoGGray(grayscale.Grayscale(img), sigma)
+}
+
+// DoGGray applies the Difference of Gaussians filter to a grayscale image: the image smoothed by a Gaussian with
+// sigma2 is subtracted from the image smoothed by a Gaussian with sigma1. With sigma2 = 1.6 * sigma1 the result
+// approximates the Laplacian of Gaussian (with opposite sign and a different scale). The response is signed and not
+// clamped. The pixels outside of the image are read as BorderReflect.
+// Example of usage:
+//
+//	response, err := edgedetection.DoGGray(img, 1, 1.6)
+func DoGGray(img *image.Gray, sigma1 float64, sigma2 float64) (*utils.Float32Image, error) {
+	if sigma1 <= 0 || sigma2 <= 0 {
+		return nil, errors.New("sigma must be bigger then 0")
+	}
+	if sigma1 == sigma2 {
+		return nil, errors.New("the two sigmas must be different")
+	}
+	radius := int(math.Ceil(3 * math.Max(sigma1, sigma2)))
+	gaussian1 := gaussianKernel(radius, sigma1)
+	gaussian2 := gaussianKernel(radius, sigma2)
+	kernel, _ := convolution.NewKernel(2*radius+1, 2*radius+1)
+	for x := 0; x < kernel.Width; x++ {
+		for y := 0; y < kernel.Height; y++ {
+			kernel.Set(x, y, gaussian1.At(x, y)-gaussian2.At(x, y))
+		}
+	}
+	return convolution.ConvolveGrayFloat(img, kernel, image.Point{X: radius, Y: radius}, padding.BorderReflect)
+}
+
+// DoGRGBA applies the Difference of Gaussians filter to the grayscale version of an RGBA image. See DoGGray.
+func DoGRGBA(img *image.RGBA, sigma1 float64, sigma2 float64) (*utils.Float32Image, error) {
+	return DoGGray(grayscale.Grayscale(img), sigma1, sigma2)
+}
+
+// ZeroCrossings extracts the edges from a signed, single channel response (such as the output of LoGGray or DoGGray)
+// as in the Marr-Hildreth edge detector. Two horizontally or vertically adjacent pixels with opposite signs which
+// differ by more than threshold form a zero crossing, from which the pixel closer to zero is marked as edge. A pixel
+// which is zero is marked if its two neighbours on the same row or column form a zero crossing. This keeps the edges
+// one pixel wide, while the threshold removes the crossings caused by noise on flat areas. Values closer to zero than
+// the rounding errors of the convolution are treated as zero. The edges are marked as white on a black background.
+// Example of usage:
+//
+//	edges, err := edgedetection.ZeroCrossings(response, 1)
+func ZeroCrossings(response *utils.Float32Image, threshold float64) (*image.Gray, error) {
+	if response.Channels != 1 {
+		return nil, errors.New("the response has more than one channel")
+	}
+	if threshold < 0 {
+		return nil, errors.New("threshold must be positive")
+	}
+	width, height := response.Width, response.Height
+	res := image.NewGray(image.Rect(0, 0, width, height))
+	sign := func(v float64) int {
+		if v > zeroCrossingEpsilon {
+			return 1
+		}
+		if v < -zeroCrossingEpsilon {
+			return -1
+		}
+		return 0
+	}
+	isCrossing := func(a, b float64) bool {
+		return sign(a)*sign(b) < 0 && math.Abs(a-b) > threshold
+	}
+	at := func(i int) float64 {
+		return float64(response.Pix[i])
+	}
+	// marks the pixel of the pair i, j which is closer to zero
+	markPair := func(i, j int) {
+		if isCrossing(at(i), at(j)) {
+			if math.Abs(at(i)) <= math.Abs(at(j)) {
+				res.Pix[i] = utils.MaxUint8
+			} else {
+				res.Pix[j] = utils.MaxUint8
+			}
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			if x+1 < width {
+				markPair(i, i+1)
+			}
+			if y+1 < height {
+				markPair(i, i+width)
+			}
+			if sign(at(i)) == 0 {
+				horizontal := x > 0 && x+1 < width && isCrossing(at(i-1), at(i+1))
+				vertical := y > 0 && y+1 < height && isCrossing(at(i-width), at(i+width))
+				if horizontal || vertical {
+					res.Pix[i] = utils.MaxUint8
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// zeroCrossingEpsilon is the absolute value below which a response is treated as zero by ZeroCrossings.
+const zeroCrossingEpsilon = 1e-4
+
+// gaussianKernel returns the normalized 2D Gaussian kernel with the size 2*radius+1.
+func gaussianKernel(radius int, sigma float64) *convolution.Kernel {
+	kernel, _ := convolution.NewKernel(2*radius+1, 2*radius+1)
+	var sum float64
+	for x := 0; x < kernel.Width; x++ {
+		for y := 0; y < kernel.Height; y++ {
+			dx, dy := float64(x-radius), float64(y-radius)
+			value := math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+			kernel.Set(x, y, value)
+			sum += value
+		}
+	}
+	for x := 0; x < kernel.Width; x++ {
+		for y := 0; y < kernel.Height; y++ {
+			kernel.Set(x, y, kernel.At(x, y)/sum)
+		}
+	}
+	return kernel
+}
@@ -0,0 +1,120 @@
+package edgedetection
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+func Test_LoGGray_Flat(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 120
+	}
+	response, err := LoGGray(img, 1.5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, v := range response.Pix {
+		if math.Abs(float64(v)) > 1e-3 {
+			t.Fatalf("Expected zero response on a flat image - actual: %f at %d", v, i)
+		}
+	}
+}
+
+func Test_LoGGray_DoGGray_StepSigns(t *testing.T) {
+	// dark to bright edge between columns 9 and 10
+	img := stepGray(20, 8, 10, 20)
+	log, err := LoGGray(img, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if log.At(9, 4, 0) <= 0 || log.At(10, 4, 0) >= 0 {
+		t.Errorf("Expected positive LoG on the dark side and negative on the bright side - actual: %f, %f",
+			log.At(9, 4, 0), log.At(10, 4, 0))
+	}
+	dog, err := DoGGray(img, 1, 1.6)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dog.At(9, 4, 0) >= 0 || dog.At(10, 4, 0) <= 0 {
+		t.Errorf("Expected negative DoG on the dark side and positive on the bright side - actual: %f, %f",
+			dog.At(9, 4, 0), dog.At(10, 4, 0))
+	}
+}
+
+func Test_ZeroCrossings_Step(t *testing.T) {
+	response, err := LoGGray(stepGray(20, 8, 10, 20), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	edges, err := ZeroCrossings(response, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for y := 0; y < 8; y++ {
+		columns := edgeColumns(edges, y)
+		if len(columns) != 1 || columns[0] < 9 || columns[0] > 10 {
+			t.Fatalf("Expected a single edge pixel at the step in row %d - actual columns: %v", y, columns)
+		}
+	}
+	edges, _ = ZeroCrossings(response, 1e6)
+	for y := 0; y < 8; y++ {
+		if columns := edgeColumns(edges, y); len(columns) != 0 {
+			t.Fatalf("Expected no edges above the contrast threshold in row %d - actual columns: %v", y, columns)
+		}
+	}
+}
+
+func Test_ZeroCrossings_MarksPixelCloserToZero(t *testing.T) {
+	response, _ := utils.NewFloat32Image(8, 1, 1)
+	// a crossing between two pixels, a crossing at a zero pixel and a zero pixel next to a negative one
+	copy(response.Pix, []float32{5, 1, -8, -9, 0, 6, 0, 0})
+	edges, err := ZeroCrossings(response, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []uint8{0, 255, 0, 0, 255, 0, 0, 0}
+	for x, v := range expected {
+		if edges.Pix[x] != v {
+			t.Errorf("Expected %v - actual %v", expected, edges.Pix)
+			break
+		}
+	}
+}
+
+func Test_LoG_DoG_ZeroCrossings_Errors(t *testing.T) {
+	img := stepGray(4, 4, 2, 4)
+	if _, err := LoGGray(img, 0); err == nil {
+		t.Error("Expected error for sigma 0")
+	}
+	if _, err := DoGGray(img, 1, 1); err == nil {
+		t.Error("Expected error for equal sigmas")
+	}
+	if _, err := DoGGray(img, -1, 1); err == nil {
+		t.Error("Expected error for negative sigma")
+	}
+	rgb, _ := utils.NewFloat32Image(2, 2, 3)
+	if _, err := ZeroCrossings(rgb, 0); err == nil {
+		t.Error("Expected error for multi-channel response")
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_LoGZeroCrossings(t *testing.T) {
+	gray := setupTestCaseGrayLapl(t)
+	response, err := LoGGray(gray, 2)
+	if err != nil {
+		t.Fatalf("Should not reach this point!")
+	}
+	edges, err := ZeroCrossings(response, 1)
+	if err != nil {
+		t.Fatalf("Should not reach this point!")
+	}
+	tearDownTestCaseLapl(t, edges, "../res/edge/logZeroCrossings.png")
+}
@@ -1,10 +1,10 @@
 package edgedetection
 
 import (
-	"github.com/ernyoke/imger/blend"
 	"github.com/ernyoke/imger/convolution"
 	"github.com/ernyoke/imger/grayscale"
 	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
 	"image"
 )
 
@@ -34,22 +34,15 @@ func VerticalSobelGray(gray *image.Gray, border padding.Border) (*image.Gray, er
 	return convolution.ConvolveGray(gray, &verticalKernel, image.Point{X: 1, Y: 1}, border)
 }
 
-// SobelGray combines the horizontal and the vertical gradients of a grayscale image. The result is grayscale image
-// which contains the high gradients ("edges") marked as white.
+// SobelGray computes the magnitude (L2 norm) of the Sobel gradient of a grayscale image. The result is grayscale image
+// which contains the high gradients ("edges") marked as white. Magnitudes above 255 are clamped. Use SobelGradient for
+// the signed derivatives and the orientation.
 func SobelGray(img *image.Gray, border padding.Border) (*image.Gray, error) {
-	horizontal, error := HorizontalSobelGray(img, border)
-	if error != nil {
-		return nil, error
-	}
-	vertical, error := VerticalSobelGray(img, border)
-	if error != nil {
-		return nil, error
-	}
-	res, error := blend.AddGrayWeighted(horizontal, 0.5, vertical, 0.5)
-	if error != nil {
-		return nil, error
+	gradient, err := SobelGradient(img, border)
+	if err != nil {
+		return nil, err
 	}
-	return res, nil
+	return gradient.Magnitude(NormL2).ToGray(utils.ConvertClamp)
 }
 
 // HorizontalSobelRGBA applies the horizontal Sobel operator (horizontal kernel) to an RGGBA image. The result
@@ -0,0 +1,172 @@
+// Package fft implements the discrete Fourier transform in one and two dimensions. Inputs whose length is a power of
+// two use the iterative radix-2 Cooley-Tukey algorithm, every other length is handled by Bluestein's algorithm, so the
+// transform of any length runs in O(n log n).
+package fft
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// FFT computes the discrete Fourier transform of x. The input is not modified.
+// Example of usage:
+//
+//	spectrum := fft.FFT([]complex128{1, 2, 3, 4})
+func FFT(x []complex128) []complex128 {
+	res := make([]complex128, len(x))
+	copy(res, x)
+	transform(res, false)
+	return res
+}
+
+// IFFT computes the inverse discrete Fourier transform of x, including the 1/n scaling, so IFFT(FFT(x)) == x.
+// The input is not modified.
+func IFFT(x []complex128) []complex128 {
+	res := make([]complex128, len(x))
+	copy(res, x)
+	transform(res, true)
+	scale(res)
+	return res
+}
+
+// FFT2 computes the 2 dimensional discrete Fourier transform of a width * height matrix stored in row-major order:
+// the value at {x, y} is data[y*width+x]. The input is not modified. Returns an error if the length of data does not
+// match the given size.
+func FFT2(data []complex128, width int, height int) ([]complex128, error) {
+	if width < 0 || height < 0 || len(data) != width*height {
+		return nil, errors.New("data length does not match the given size")
+	}
+	res := make([]complex128, len(data))
+	copy(res, data)
+	transform2(res, width, height, false)
+	return res, nil
+}
+
+// IFFT2 computes the 2 dimensional inverse discrete Fourier transform of a width * height matrix stored in row-major
+// order, including the 1/(width*height) scaling. See FFT2.
+func IFFT2(data []complex128, width int, height int) ([]complex128, error) {
+	if width < 0 || height < 0 || len(data) != width*height {
+		return nil, errors.New("data length does not match the given size")
+	}
+	res := make([]complex128, len(data))
+	copy(res, data)
+	transform2(res, width, height, true)
+	scale(res)
+	return res, nil
+}
+
+// NextPowerOfTwo returns the smallest power of two which is greater than or equal to n. Padding data to this length
+// selects the fastest (radix-2) transform.
+func NextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << uint(bits.Len(uint(n-1)))
+}
+
+// -------------------------------------------------------------------------------------------------------
+func transform2(data []complex128, width int, height int, inverse bool) {
+	for y := 0; y < height; y++ {
+		transform(data[y*width:(y+1)*width], inverse)
+	}
+	column := make([]complex128, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			column[y] = data[y*width+x]
+		}
+		transform(column, inverse)
+		for y := 0; y < height; y++ {
+			data[y*width+x] = column[y]
+		}
+	}
+}
+
+// transform computes the unscaled (inverse) discrete Fourier transform of x in place.
+func transform(x []complex128, inverse bool) {
+	n := len(x)
+	switch {
+	case n <= 1:
+		return
+	case n&(n-1) == 0:
+		radix2(x, inverse)
+	default:
+		bluestein(x, inverse)
+	}
+}
+
+// radix2 is the iterative Cooley-Tukey algorithm, len(x) has to be a power of two.
+func radix2(x []complex128, inverse bool) {
+	n := len(x)
+	shift := uint(64 - bits.Len(uint(n-1)))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size >> 1
+		angle := sign * 2 * math.Pi / float64(size)
+		for k := 0; k < half; k++ {
+			w := cmplx.Rect(1, angle*float64(k))
+			for start := 0; start < n; start += size {
+				even := x[start+k]
+				odd := x[start+k+half] * w
+				x[start+k] = even + odd
+				x[start+k+half] = even - odd
+			}
+		}
+	}
+}
+
+// bluestein expresses a transform of arbitrary length as a circular convolution, which is computed with radix-2
+// transforms of a padded length.
+func bluestein(x []complex128, inverse bool) {
+	n := len(x)
+	m := NextPowerOfTwo(2*n - 1)
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	// chirp[k] = exp(sign * i * pi * k^2 / n), k^2 is reduced modulo 2n to keep the angle accurate
+	chirp := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		k2 := (uint64(k) * uint64(k)) % uint64(2*n)
+		chirp[k] = cmplx.Rect(1, sign*math.Pi*float64(k2)/float64(n))
+	}
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = x[k] * chirp[k]
+	}
+	b[0] = cmplx.Conj(chirp[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(chirp[k])
+		b[m-k] = b[k]
+	}
+	radix2(a, false)
+	radix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	radix2(a, true)
+	for k := 0; k < n; k++ {
+		x[k] = a[k] / complex(float64(m), 0) * chirp[k]
+	}
+}
+
+func scale(x []complex128) {
+	if len(x) == 0 {
+		return
+	}
+	factor := complex(1/float64(len(x)), 0)
+	for i := range x {
+		x[i] *= factor
+	}
+}
@@ -0,0 +1,101 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// ---------------------------------Unit tests------------------------------------
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	res := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		for j := 0; j < n; j++ {
+			res[k] += x[j] * cmplx.Rect(1, -2*math.Pi*float64(j*k)/float64(n))
+		}
+	}
+	return res
+}
+
+func testSignal(n int) []complex128 {
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Sin(float64(i))*10+float64(i%3), math.Cos(float64(i*i)))
+	}
+	return x
+}
+
+func compareComplex(t *testing.T, expected []complex128, actual []complex128) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Fatalf("Expected length: %d - actual length: %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if cmplx.Abs(expected[i]-actual[i]) > 1e-8 {
+			t.Errorf("Expected: %v - actual: %v at %d", expected[i], actual[i], i)
+		}
+	}
+}
+
+func Test_FFT_MatchesDFT(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 5, 8, 12, 16, 17, 100} {
+		x := testSignal(n)
+		compareComplex(t, naiveDFT(x), FFT(x))
+	}
+}
+
+func Test_IFFT_RoundTrip(t *testing.T) {
+	for _, n := range []int{1, 7, 32, 45} {
+		x := testSignal(n)
+		compareComplex(t, x, IFFT(FFT(x)))
+	}
+}
+
+func Test_FFT2_MatchesDFT(t *testing.T) {
+	width, height := 6, 4
+	data := testSignal(width * height)
+	actual, err := FFT2(data, width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the 2D transform is the 1D transform of the columns of the transformed rows
+	expected := make([]complex128, width*height)
+	for y := 0; y < height; y++ {
+		copy(expected[y*width:], naiveDFT(data[y*width:(y+1)*width]))
+	}
+	for x := 0; x < width; x++ {
+		column := make([]complex128, height)
+		for y := range column {
+			column[y] = expected[y*width+x]
+		}
+		for y, v := range naiveDFT(column) {
+			expected[y*width+x] = v
+		}
+	}
+	compareComplex(t, expected, actual)
+
+	inverse, err := IFFT2(actual, width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareComplex(t, data, inverse)
+}
+
+func Test_FFT2_InvalidSize(t *testing.T) {
+	if _, err := FFT2(make([]complex128, 10), 3, 3); err == nil {
+		t.Error("Expected an error for a size mismatch")
+	}
+	if _, err := IFFT2(make([]complex128, 10), 5, -2); err == nil {
+		t.Error("Expected an error for a negative size")
+	}
+}
+
+func Test_NextPowerOfTwo(t *testing.T) {
+	cases := map[int]int{0: 1, 1: 1, 2: 2, 3: 4, 16: 16, 17: 32, 1000: 1024}
+	for n, expected := range cases {
+		if actual := NextPowerOfTwo(n); actual != expected {
+			t.Errorf("Expected: %d - actual: %d for %d", expected, actual, n)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package histogram
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// EqualizeGray spreads the gray levels of an image over the full range, so that their cumulative distribution becomes
+// close to linear. This increases the global contrast of images which use only a part of the range.
+// Example of usage:
+//
+//	res := histogram.EqualizeGray(img)
+func EqualizeGray(img *image.Gray) *image.Gray {
+	hist := HistogramGray(img)
+	return applyLUT(img, equalizationLUT(hist))
+}
+
+// EqualizeRGBA equalizes the luminance of an RGBA image, see EqualizeGray. The image is converted to YCbCr and only
+// its Y channel is changed, so the hues do not shift. The alpha channel is kept.
+// Example of usage:
+//
+//	res := histogram.EqualizeRGBA(img)
+func EqualizeRGBA(img *image.RGBA) *image.RGBA {
+	return mapLuminance(img, EqualizeGray)
+}
+
+// CLAHEGray applies contrast limited adaptive histogram equalization to a grayscale image. The image is split into
+// tileGrid.X * tileGrid.Y tiles and every tile is equalized using its own histogram, whose bins are clipped at
+// clipLimit times the average bin count first, with the clipped counts redistributed evenly. The clipping limits the
+// amplification of the noise on flat areas. A clipLimit of 0 disables the clipping. To avoid visible tile borders, the
+// value of every pixel is interpolated bilinearly from the mappings of the four nearest tiles.
+// Example of usage:
+//
+//	res, err := histogram.CLAHEGray(img, image.Point{X: 8, Y: 8}, 2)
+func CLAHEGray(img *image.Gray, tileGrid image.Point, clipLimit float64) (*image.Gray, error) {
+	size := img.Bounds().Size()
+	if tileGrid.X < 1 || tileGrid.Y < 1 {
+		return nil, errors.New("tile grid must be at least 1x1")
+	}
+	if tileGrid.X > size.X || tileGrid.Y > size.Y {
+		return nil, errors.New("tile grid can not have more tiles than pixels")
+	}
+	if clipLimit < 0 {
+		return nil, errors.New("clip limit must be positive")
+	}
+	offset := img.Bounds().Min
+	// the mapping of every tile, row by row
+	luts := make([][hsize]uint8, tileGrid.X*tileGrid.Y)
+	for ty := 0; ty < tileGrid.Y; ty++ {
+		for tx := 0; tx < tileGrid.X; tx++ {
+			x0, x1 := tx*size.X/tileGrid.X, (tx+1)*size.X/tileGrid.X
+			y0, y1 := ty*size.Y/tileGrid.Y, (ty+1)*size.Y/tileGrid.Y
+			var hist [hsize]uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					hist[img.Pix[img.PixOffset(x+offset.X, y+offset.Y)]]++
+				}
+			}
+			area := uint64((x1 - x0) * (y1 - y0))
+			if clipLimit > 0 {
+				limit := uint64(math.Max(1, clipLimit*float64(area)/hsize))
+				clipHistogram(&hist, limit)
+			}
+			luts[ty*tileGrid.X+tx] = claheLUT(hist, area)
+		}
+	}
+
+	tileWidth := float64(size.X) / float64(tileGrid.X)
+	tileHeight := float64(size.Y) / float64(tileGrid.Y)
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		ty0, ty1, wy := interpolationTiles(y, tileHeight, tileGrid.Y)
+		for x := 0; x < size.X; x++ {
+			tx0, tx1, wx := interpolationTiles(x, tileWidth, tileGrid.X)
+			v := img.Pix[img.PixOffset(x+offset.X, y+offset.Y)]
+			top := (1-wx)*float64(luts[ty0*tileGrid.X+tx0][v]) + wx*float64(luts[ty0*tileGrid.X+tx1][v])
+			bottom := (1-wx)*float64(luts[ty1*tileGrid.X+tx0][v]) + wx*float64(luts[ty1*tileGrid.X+tx1][v])
+			res.Pix[y*res.Stride+x] = uint8(math.Round((1-wy)*top + wy*bottom))
+		}
+	}
+	return res, nil
+}
+
+// CLAHERGBA applies contrast limited adaptive histogram equalization to the luminance of an RGBA image, see
+// CLAHEGray. The image is converted to YCbCr and only its Y channel is changed, so the hues do not shift. The alpha
+// channel is kept.
+// Example of usage:
+//
+//	res, err := histogram.CLAHERGBA(img, image.Point{X: 8, Y: 8}, 2)
+func CLAHERGBA(img *image.RGBA, tileGrid image.Point, clipLimit float64) (*image.RGBA, error) {
+	var err error
+	res := mapLuminance(img, func(luminance *image.Gray) *image.Gray {
+		var equalized *image.Gray
+		equalized, err = CLAHEGray(luminance, tileGrid, clipLimit)
+		return equalized
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// equalizationLUT maps the gray levels so that the first used level becomes 0 and the last one 255. An image with a
+// single gray level is left unchanged.
+func equalizationLUT(hist [hsize]uint64) [hsize]uint8 {
+	var lut [hsize]uint8
+	var total, cdfMin uint64
+	for _, bin := range hist {
+		total += bin
+	}
+	for _, bin := range hist {
+		if bin > 0 {
+			cdfMin = bin
+			break
+		}
+	}
+	var cdf uint64
+	for i, bin := range hist {
+		cdf += bin
+		if total == cdfMin {
+			lut[i] = uint8(i)
+		} else if cdf > cdfMin {
+			lut[i] = uint8(math.Round(float64(cdf-cdfMin) * 255 / float64(total-cdfMin)))
+		}
+	}
+	return lut
+}
+
+// clipHistogram limits every bin to limit and redistributes the removed counts evenly between the bins.
+func clipHistogram(hist *[hsize]uint64, limit uint64) {
+	var excess uint64
+	for i, bin := range hist {
+		if bin > limit {
+			excess += bin - limit
+			hist[i] = limit
+		}
+	}
+	batch, residual := excess/hsize, excess%hsize
+	for i := range hist {
+		hist[i] += batch
+	}
+	if residual > 0 {
+		step := hsize / residual
+		for i := uint64(0); i < hsize && residual > 0; i += step {
+			hist[i]++
+			residual--
+		}
+	}
+}
+
+// claheLUT maps the gray levels of a tile to its cumulative distribution.
+func claheLUT(hist [hsize]uint64, area uint64) [hsize]uint8 {
+	var lut [hsize]uint8
+	var cdf uint64
+	for i, bin := range hist {
+		cdf += bin
+		lut[i] = uint8(math.Min(255, math.Round(float64(cdf)*255/float64(area))))
+	}
+	return lut
+}
+
+// interpolationTiles returns the two tiles whose centers surround the position and the weight of the second one.
+// Outside of the outermost centers, both tiles are the outermost one.
+func interpolationTiles(position int, tileSize float64, tiles int) (int, int, float64) {
+	t := (float64(position)+0.5)/tileSize - 0.5
+	t0 := int(math.Floor(t))
+	weight := t - float64(t0)
+	t1 := t0 + 1
+	if t0 < 0 {
+		t0 = 0
+	}
+	if t1 > tiles-1 {
+		t1 = tiles - 1
+	}
+	return t0, t1, weight
+}
+
+func applyLUT(img *image.Gray, lut [hsize]uint8) *image.Gray {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			res.Pix[y*res.Stride+x] = lut[img.Pix[img.PixOffset(x+offset.X, y+offset.Y)]]
+		}
+	}
+	return res
+}
+
+// mapLuminance converts an RGBA image to YCbCr, transforms its Y channel using f and converts it back.
+func mapLuminance(img *image.RGBA, f func(*image.Gray) *image.Gray) *image.RGBA {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	luminance := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	chroma := make([][2]uint8, size.X*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			pixel := img.RGBAAt(x+offset.X, y+offset.Y)
+			yy, cb, cr := color.RGBToYCbCr(pixel.R, pixel.G, pixel.B)
+			luminance.Pix[y*luminance.Stride+x] = yy
+			chroma[y*size.X+x] = [2]uint8{cb, cr}
+		}
+	}
+	mapped := f(luminance)
+	if mapped == nil {
+		return nil
+	}
+	res := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			c := chroma[y*size.X+x]
+			r, g, b := color.YCbCrToRGB(mapped.Pix[y*mapped.Stride+x], c[0], c[1])
+			res.SetRGBA(x, y, color.RGBA{R: r, G: g, B: b, A: img.RGBAAt(x+offset.X, y+offset.Y).A})
+		}
+	}
+	return res
+}
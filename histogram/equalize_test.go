@@ -0,0 +1,136 @@
+package histogram
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// --------------------------------Unit tests---------------------------------------
+
+func Test_EqualizeGray(t *testing.T) {
+	img := &image.Gray{Rect: image.Rect(0, 0, 5, 1), Stride: 5, Pix: []uint8{50, 51, 52, 53, 53}}
+	expected := &image.Gray{Rect: image.Rect(0, 0, 5, 1), Stride: 5, Pix: []uint8{0, 64, 128, 255, 255}}
+	utils.CompareGrayImages(t, expected, EqualizeGray(img))
+
+	// a single gray level can not be spread
+	flat := &image.Gray{Rect: image.Rect(0, 0, 2, 1), Stride: 2, Pix: []uint8{77, 77}}
+	utils.CompareGrayImages(t, flat, EqualizeGray(flat))
+}
+
+func Test_EqualizeRGBA(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{R: 120, G: 120, B: 120, A: 200})
+	img.SetRGBA(2, 0, color.RGBA{R: 140, G: 100, B: 100, A: 255})
+	res := EqualizeRGBA(img)
+	first, second, third := res.RGBAAt(0, 0), res.RGBAAt(1, 0), res.RGBAAt(2, 0)
+	if first != (color.RGBA{A: 255}) {
+		t.Errorf("Expected the darkest pixel to become black - actual %v", first)
+	}
+	if second.R != second.G || second.G != second.B || second.A != 200 {
+		t.Errorf("Expected a gray pixel to stay gray and keep its alpha - actual %v", second)
+	}
+	if third.R <= third.G || third.G != third.B {
+		t.Errorf("Expected a red pixel to stay red - actual %v", third)
+	}
+}
+
+func Test_CLAHEGray_IdenticalTiles(t *testing.T) {
+	// every 8x8 tile contains the gray levels 100..163 once, so every tile has the same mapping
+	img := image.NewGray(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			img.Pix[y*img.Stride+x] = uint8(100 + x%8 + 8*(y%8))
+		}
+	}
+	res, err := CLAHEGray(img, image.Point{X: 4, Y: 2}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the interpolation between equal mappings gives the mapping itself: the cumulative distribution of the tile
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			k := float64(img.Pix[y*img.Stride+x]) - 99
+			if expected := uint8(math.Round(k * 255 / 64)); res.Pix[y*res.Stride+x] != expected {
+				t.Fatalf("At (%d, %d): expected %d - actual %d", x, y, expected, res.Pix[y*res.Stride+x])
+			}
+		}
+	}
+}
+
+func Test_CLAHEGray_Cropped(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 40, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 40; x++ {
+			img.Pix[y*img.Stride+x] = uint8(100 + x)
+		}
+	}
+	// the narrow range of the ramp is stretched to the full range
+	res, err := CLAHEGray(img.SubImage(image.Rect(8, 0, 40, 10)).(*image.Gray), image.Point{X: 1, Y: 1}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if res.Bounds() != image.Rect(0, 0, 32, 10) || res.Pix[0] != 8 || res.Pix[31] != 255 {
+		t.Errorf("Expected the ramp to be stretched from 8 to 255 - actual %d to %d", res.Pix[0], res.Pix[31])
+	}
+}
+
+func Test_CLAHEGray_ClipLimit(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 100
+	}
+	unclipped, err := CLAHEGray(img, image.Point{X: 2, Y: 2}, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	clipped, err := CLAHEGray(img, image.Point{X: 2, Y: 2}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// without clipping a flat area is mapped to white, the clipping keeps it close to the original value
+	if unclipped.Pix[0] != 255 || clipped.Pix[0] < 90 || clipped.Pix[0] > 110 {
+		t.Errorf("Expected 255 without and about 100 with clipping - actual %d and %d", unclipped.Pix[0],
+			clipped.Pix[0])
+	}
+	for i := range clipped.Pix {
+		if clipped.Pix[i] != clipped.Pix[0] {
+			t.Fatalf("Expected a flat result - actual %v", clipped.Pix)
+		}
+	}
+}
+
+func Test_CLAHEGray_Invalid(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for _, grid := range []image.Point{{X: 0, Y: 1}, {X: 1, Y: -1}, {X: 5, Y: 1}, {X: 1, Y: 5}} {
+		if _, err := CLAHEGray(img, grid, 2); err == nil {
+			t.Errorf("Expected error for tile grid %v", grid)
+		}
+	}
+	if _, err := CLAHEGray(img, image.Point{X: 2, Y: 2}, -1); err == nil {
+		t.Error("Expected error for negative clip limit")
+	}
+	if _, err := CLAHERGBA(image.NewRGBA(img.Bounds()), image.Point{}, 2); err == nil {
+		t.Error("Expected error for empty tile grid")
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_CLAHEGray(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	res, err := CLAHEGray(gray, image.Point{X: 8, Y: 8}, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tearDownTestCase(t, res, "../res/histogram/claheGray.jpg")
+}
+
+func Test_Acceptance_EqualizeRGBA(t *testing.T) {
+	rgba := setupTestCaseRGBA(t)
+	tearDownTestCase(t, EqualizeRGBA(rgba), "../res/histogram/equalizeRGBA.jpg")
+}
@@ -66,11 +66,9 @@ func HistogramRGBA(img *image.RGBA) [channels][hsize]uint64 {
 // and 256*scale height.
 func DrawHistogramGray(img *image.Gray, size image.Point) *image.Gray {
 	h := HistogramGray(img)
-	normHist := normalizeHistogram(h, uint64(size.Y))
+	normHist := normalizeHistogram(h[:], uint64(size.Y))
 	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
-	drawerFunc(size, func(i int) uint64 {
-		return normHist[i]
-	}, func(x, y int) {
+	drawerFunc(size, normHist, func(x, y int) {
 		res.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
 	})
 	return res
@@ -80,27 +78,25 @@ func DrawHistogramGray(img *image.Gray, size image.Point) *image.Gray {
 // 256*scale height.
 func DrawHistogramRGBA(img *image.RGBA, size image.Point) *image.RGBA {
 	h := HistogramRGBA(img)
-	normRHist := normalizeHistogram(h[0], uint64(size.Y))
-	normGHist := normalizeHistogram(h[1], uint64(size.Y))
-	normBHist := normalizeHistogram(h[2], uint64(size.Y))
+	return drawChannels(h[0][:], h[1][:], h[2][:], size)
+}
+
+// ---------------------------------------------------------------------------------------------
+// drawChannels draws the histograms of the red, green and blue channels on top of each other, each using its own
+// color.
+func drawChannels(red []uint64, green []uint64, blue []uint64, size image.Point) *image.RGBA {
 	res := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
-	drawerFunc(size, func(i int) uint64 {
-		return normRHist[i]
-	}, func(x, y int) {
+	drawerFunc(size, normalizeHistogram(red, uint64(size.Y)), func(x, y int) {
 		pix := res.RGBAAt(x, y)
 		pix.R = utils.MaxUint8
 		res.SetRGBA(x, y, pix)
 	})
-	drawerFunc(size, func(i int) uint64 {
-		return normGHist[i]
-	}, func(x, y int) {
+	drawerFunc(size, normalizeHistogram(green, uint64(size.Y)), func(x, y int) {
 		pix := res.RGBAAt(x, y)
 		pix.G = utils.MaxUint8
 		res.SetRGBA(x, y, pix)
 	})
-	drawerFunc(size, func(i int) uint64 {
-		return normBHist[i]
-	}, func(x, y int) {
+	drawerFunc(size, normalizeHistogram(blue, uint64(size.Y)), func(x, y int) {
 		pix := res.RGBAAt(x, y)
 		pix.B = utils.MaxUint8
 		res.SetRGBA(x, y, pix)
@@ -108,21 +104,34 @@ func DrawHistogramRGBA(img *image.RGBA, size image.Point) *image.RGBA {
 	return res
 }
 
-// ---------------------------------------------------------------------------------------------
-func drawerFunc(size image.Point, getNormAt func(i int) uint64, setPixel func(x, y int)) {
-	scaleX := float64(size.X) / float64(hsize)
-	for i := 0; i < hsize; i++ {
-		for width := int(float64(i) * scaleX); width < int((float64(i)+1.0)*scaleX); width++ {
-			for height := size.Y; height >= size.Y-int(getNormAt(i)); height-- {
-				setPixel(width, height)
+// drawerFunc draws the bars of a normalized histogram. Every column shows the highest bin it covers, so histograms
+// with more bins than columns can be drawn as well.
+func drawerFunc(size image.Point, norm []uint64, setPixel func(x, y int)) {
+	bins := len(norm)
+	for x := 0; x < size.X; x++ {
+		start := x * bins / size.X
+		end := (x + 1) * bins / size.X
+		if end <= start {
+			end = start + 1
+		}
+		var barHeight uint64
+		for i := start; i < end; i++ {
+			if norm[i] > barHeight {
+				barHeight = norm[i]
 			}
 		}
+		for height := size.Y; height >= size.Y-int(barHeight); height-- {
+			setPixel(x, height)
+		}
 	}
 }
 
-func normalizeHistogram(v [hsize]uint64, maxHeight uint64) [hsize]uint64 {
-	max := utils.GetMax(v[:])
-	var norm [hsize]uint64
+func normalizeHistogram(v []uint64, maxHeight uint64) []uint64 {
+	max := utils.GetMax(v)
+	norm := make([]uint64, len(v))
+	if max == 0 {
+		return norm
+	}
 	for i := 0; i < len(v); i++ {
 		norm[i] = v[i] * maxHeight / max
 	}
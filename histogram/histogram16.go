@@ -0,0 +1,104 @@
+package histogram
+
+import (
+	"errors"
+	"image"
+	"image/color"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// MaxBins16 is the number of bins which gives every 16 bit value its own bin.
+const MaxBins16 = 1 << 16
+
+// HistogramGray16 computes the histogram of a 16 bit grayscale image using the given number of bins, which has to be
+// between 1 and MaxBins16. The bins split the range of the 16 bit values evenly: the value v is counted in the bin
+// v * bins / 65536.
+// Example of usage:
+//
+//	hist, err := histogram.HistogramGray16(img, 1024)
+func HistogramGray16(img *image.Gray16, bins int) ([]uint64, error) {
+	if err := validateBins(bins); err != nil {
+		return nil, err
+	}
+	res := make([]uint64, bins)
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			res[bin16(img.Gray16At(x+offset.X, y+offset.Y).Y, bins)]++
+		}
+	}
+	return res, nil
+}
+
+// HistogramRGBA64 computes the histograms of the red, green and blue channels of a 16 bit RGBA image using the given
+// number of bins, which has to be between 1 and MaxBins16. The values are binned the same way as by HistogramGray16.
+// Example of usage:
+//
+//	hist, err := histogram.HistogramRGBA64(img, 1024)
+//	red, green, blue := hist[0], hist[1], hist[2]
+func HistogramRGBA64(img *image.RGBA64, bins int) ([channels][]uint64, error) {
+	var res [channels][]uint64
+	if err := validateBins(bins); err != nil {
+		return res, err
+	}
+	for c := range res {
+		res[c] = make([]uint64, bins)
+	}
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			pixel := img.RGBA64At(x+offset.X, y+offset.Y)
+			res[0][bin16(pixel.R, bins)]++
+			res[1][bin16(pixel.G, bins)]++
+			res[2][bin16(pixel.B, bins)]++
+		}
+	}
+	return res, nil
+}
+
+// DrawHistogramGray16 computes the histogram of a 16 bit grayscale image with the given number of bins and draws it
+// into an image of the given size. If there are more bins than columns, every column shows the highest of its bins.
+// Example of usage:
+//
+//	res, err := histogram.DrawHistogramGray16(img, 1024, image.Point{X: 512, Y: 300})
+func DrawHistogramGray16(img *image.Gray16, bins int, size image.Point) (*image.Gray, error) {
+	h, err := HistogramGray16(img, bins)
+	if err != nil {
+		return nil, err
+	}
+	normHist := normalizeHistogram(h, uint64(size.Y))
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	drawerFunc(size, normHist, func(x, y int) {
+		res.SetGray(x, y, color.Gray{Y: utils.MaxUint8})
+	})
+	return res, nil
+}
+
+// DrawHistogramRGBA64 computes the histograms of a 16 bit RGBA image with the given number of bins and draws them into
+// an image of the given size, the histogram of every channel using its own color. If there are more bins than
+// columns, every column shows the highest of its bins.
+// Example of usage:
+//
+//	res, err := histogram.DrawHistogramRGBA64(img, 1024, image.Point{X: 512, Y: 300})
+func DrawHistogramRGBA64(img *image.RGBA64, bins int, size image.Point) (*image.RGBA, error) {
+	h, err := HistogramRGBA64(img, bins)
+	if err != nil {
+		return nil, err
+	}
+	return drawChannels(h[0], h[1], h[2], size), nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+func validateBins(bins int) error {
+	if bins < 1 || bins > MaxBins16 {
+		return errors.New("number of bins must be between 1 and 65536")
+	}
+	return nil
+}
+
+func bin16(value uint16, bins int) int {
+	return int(value) * bins / MaxBins16
+}
@@ -0,0 +1,113 @@
+package histogram
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/ernyoke/imger/imgio"
+)
+
+// --------------------------------Unit tests---------------------------------------
+
+func Test_Histogram_Gray16(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 3, 2))
+	for i, v := range []uint16{0, 255, 256, 40000, 65535, 65535} {
+		img.SetGray16(i%3, i/3, color.Gray16{Y: v})
+	}
+	hist, err := HistogramGray16(img, MaxBins16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(hist) != MaxBins16 || hist[0] != 1 || hist[255] != 1 || hist[256] != 1 || hist[40000] != 1 ||
+		hist[65535] != 2 {
+		t.Errorf("Expected every value in its own bin")
+	}
+	hist, err = HistogramGray16(img, 256)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[int]uint64{0: 2, 1: 1, 156: 1, 255: 2}
+	for i, h := range hist {
+		if h != expected[i] {
+			t.Errorf("Histogram value for bin %d should be %d - actual %d", i, expected[i], h)
+		}
+	}
+	hist, _ = HistogramGray16(img.SubImage(image.Rect(1, 1, 3, 2)).(*image.Gray16), 1)
+	if len(hist) != 1 || hist[0] != 2 {
+		t.Errorf("Expected a single bin with the 2 pixels of the sub-image - actual %v", hist)
+	}
+	for _, bins := range []int{-1, 0, MaxBins16 + 1} {
+		if _, err := HistogramGray16(img, bins); err == nil {
+			t.Errorf("Expected error for %d bins", bins)
+		}
+	}
+}
+
+func Test_Histogram_RGBA64(t *testing.T) {
+	img := image.NewRGBA64(image.Rect(0, 0, 2, 1))
+	img.SetRGBA64(0, 0, color.RGBA64{R: 0, G: 32768, B: 65535, A: 65535})
+	img.SetRGBA64(1, 0, color.RGBA64{R: 100, G: 32767, B: 65535, A: 65535})
+	hist, err := HistogramRGBA64(img, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := [channels][]uint64{{2, 0}, {1, 1}, {0, 2}}
+	for c := range expected {
+		for i := range expected[c] {
+			if hist[c][i] != expected[c][i] {
+				t.Errorf("Expected histograms %v - actual %v", expected, hist)
+			}
+		}
+	}
+	if _, err := HistogramRGBA64(img, 0); err == nil {
+		t.Error("Expected error for 0 bins")
+	}
+}
+
+func Test_DrawHistogram_Gray16(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 4, 1))
+	for i, v := range []uint16{0, 0, 100, 65535} {
+		img.SetGray16(i, 0, color.Gray16{Y: v})
+	}
+	// more bins than columns: the bins of the first column are 0 and 100, the highest of them is drawn
+	res, err := DrawHistogramGray16(img, MaxBins16, image.Point{X: 4, Y: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	column := func(x int) int {
+		n := 0
+		for y := 0; y < 10; y++ {
+			if res.GrayAt(x, y).Y != 0 {
+				n++
+			}
+		}
+		return n
+	}
+	if column(0) != 10 || column(1) != 0 || column(2) != 0 || column(3) != 5 {
+		t.Errorf("Expected bars of 10, 0, 0, 5 pixels - actual %d, %d, %d, %d", column(0), column(1), column(2),
+			column(3))
+	}
+	if _, err := DrawHistogramGray16(img, 0, image.Point{X: 4, Y: 10}); err == nil {
+		t.Error("Expected error for 0 bins")
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_DrawHistogram_RGBA64(t *testing.T) {
+	path := "../res/girl.jpg"
+	rgba, err := imgio.ImreadRGBA64(path)
+	if err != nil {
+		t.Fatalf("Could not read image from path: %s", path)
+	}
+	expectedSize := image.Point{X: 512, Y: 600}
+	hist, err := DrawHistogramRGBA64(rgba, 1024, expectedSize)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if actualSize := hist.Bounds().Size(); actualSize != expectedSize {
+		t.Fatalf("Size of expected %v does not match size of actual %v", expectedSize, actualSize)
+	}
+	tearDownTestCase(t, hist, "../res/histogram/rgba64.jpg")
+}
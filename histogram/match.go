@@ -0,0 +1,165 @@
+package histogram
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// MatchMode is an enum type for the ways the histograms of RGBA images are matched
+type MatchMode int
+
+const (
+	// MatchPerChannel - the red, green and blue channels are matched independently
+	MatchPerChannel MatchMode = iota
+	// MatchLuminance - only the Y channel of the YCbCr representation is matched, so the hues do not shift
+	MatchLuminance
+)
+
+// MatchHistogramGray transforms the gray levels of src so that its histogram becomes similar to the histogram of
+// reference (histogram specification). Every gray level is mapped to the lowest level of the reference whose
+// cumulative distribution reaches the cumulative distribution of the level in src. The reference can not be empty.
+// Example of usage:
+//
+//	res, err := histogram.MatchHistogramGray(img, reference)
+func MatchHistogramGray(src *image.Gray, reference *image.Gray) (*image.Gray, error) {
+	return MatchHistogramGrayTo(src, HistogramGray(reference))
+}
+
+// MatchHistogramGrayTo transforms the gray levels of src so that its histogram becomes similar to the target
+// histogram, see MatchHistogramGray. Only the shape of the target matters, it can be scaled arbitrarily, but it can not
+// be empty.
+// Example of usage:
+//
+//	// a flat histogram
+//	var target [256]uint64
+//	for i := range target {
+//		target[i] = 1
+//	}
+//	res, err := histogram.MatchHistogramGrayTo(img, target)
+func MatchHistogramGrayTo(src *image.Gray, target [hsize]uint64) (*image.Gray, error) {
+	lut, err := matchingLUT(HistogramGray(src), target)
+	if err != nil {
+		return nil, err
+	}
+	return applyLUT(src, lut), nil
+}
+
+// MatchHistogramRGBA transforms the colors of src so that its histograms become similar to the histograms of
+// reference, see MatchHistogramGray. The mode selects whether the color channels are matched independently or only the
+// luminance is matched. The alpha channel is kept.
+// Example of usage:
+//
+//	res, err := histogram.MatchHistogramRGBA(img, reference, histogram.MatchLuminance)
+func MatchHistogramRGBA(src *image.RGBA, reference *image.RGBA, mode MatchMode) (*image.RGBA, error) {
+	switch mode {
+	case MatchPerChannel:
+		return MatchHistogramRGBATo(src, HistogramRGBA(reference))
+	case MatchLuminance:
+		return MatchLuminanceRGBATo(src, luminanceHistogram(reference))
+	}
+	return nil, errors.New("invalid match mode")
+}
+
+// MatchHistogramRGBATo transforms the red, green and blue channels of src independently, so that their histograms
+// become similar to the target histograms of the channels, see MatchHistogramGrayTo. The alpha channel is kept.
+// Example of usage:
+//
+//	res, err := histogram.MatchHistogramRGBATo(img, histogram.HistogramRGBA(reference))
+func MatchHistogramRGBATo(src *image.RGBA, target [channels][hsize]uint64) (*image.RGBA, error) {
+	hist := HistogramRGBA(src)
+	var luts [channels][hsize]uint8
+	for c := range luts {
+		var err error
+		if luts[c], err = matchingLUT(hist[c], target[c]); err != nil {
+			return nil, err
+		}
+	}
+	size := src.Bounds().Size()
+	offset := src.Bounds().Min
+	res := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			pixel := src.RGBAAt(x+offset.X, y+offset.Y)
+			res.SetRGBA(x, y, color.RGBA{R: luts[0][pixel.R], G: luts[1][pixel.G], B: luts[2][pixel.B], A: pixel.A})
+		}
+	}
+	return res, nil
+}
+
+// MatchLuminanceRGBATo transforms the luminance of src so that its histogram becomes similar to the target
+// histogram, see MatchHistogramGrayTo. The image is converted to YCbCr and only its Y channel is changed, so the hues
+// do not shift. The alpha channel is kept.
+// Example of usage:
+//
+//	res, err := histogram.MatchLuminanceRGBATo(img, histogram.HistogramGray(grayReference))
+func MatchLuminanceRGBATo(src *image.RGBA, target [hsize]uint64) (*image.RGBA, error) {
+	if isEmpty(target[:]) {
+		return nil, errors.New("target histogram can not be empty")
+	}
+	return mapLuminance(src, func(luminance *image.Gray) *image.Gray {
+		res, _ := MatchHistogramGrayTo(luminance, target)
+		return res
+	}), nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// matchingCDFEpsilon absorbs the rounding errors of the normalized cumulative distributions.
+const matchingCDFEpsilon = 1e-12
+
+// matchingLUT maps every level of the source histogram to the lowest level of the target histogram with at least the
+// same cumulative distribution.
+func matchingLUT(source [hsize]uint64, target [hsize]uint64) ([hsize]uint8, error) {
+	var lut [hsize]uint8
+	if isEmpty(target[:]) {
+		return lut, errors.New("target histogram can not be empty")
+	}
+	sourceCDF, targetCDF := normalizedCDF(source), normalizedCDF(target)
+	j := 0
+	for i := range lut {
+		for j < hsize-1 && targetCDF[j] < sourceCDF[i]-matchingCDFEpsilon {
+			j++
+		}
+		lut[i] = uint8(j)
+	}
+	return lut, nil
+}
+
+func normalizedCDF(hist [hsize]uint64) [hsize]float64 {
+	var cdf [hsize]float64
+	var sum uint64
+	for i, bin := range hist {
+		sum += bin
+		cdf[i] = float64(sum)
+	}
+	if sum > 0 {
+		for i := range cdf {
+			cdf[i] /= float64(sum)
+		}
+	}
+	return cdf
+}
+
+func isEmpty(hist []uint64) bool {
+	for _, bin := range hist {
+		if bin > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// luminanceHistogram computes the histogram of the Y channel of the YCbCr representation of an RGBA image.
+func luminanceHistogram(img *image.RGBA) [hsize]uint64 {
+	var res [hsize]uint64
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			pixel := img.RGBAAt(x+offset.X, y+offset.Y)
+			yy, _, _ := color.RGBToYCbCr(pixel.R, pixel.G, pixel.B)
+			res[yy]++
+		}
+	}
+	return res
+}
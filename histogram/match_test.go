@@ -0,0 +1,117 @@
+package histogram
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/utils"
+)
+
+// --------------------------------Unit tests---------------------------------------
+
+func Test_MatchHistogramGray(t *testing.T) {
+	src := &image.Gray{Rect: image.Rect(0, 0, 4, 1), Stride: 4, Pix: []uint8{0, 1, 2, 3}}
+	reference := &image.Gray{Rect: image.Rect(0, 0, 4, 1), Stride: 4, Pix: []uint8{20, 10, 20, 10}}
+	res, err := MatchHistogramGray(src, reference)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the lower half of src goes to the lower half of the reference
+	utils.CompareGrayImages(t, &image.Gray{Rect: src.Rect, Stride: 4, Pix: []uint8{10, 10, 20, 20}}, res)
+
+	// matching an image to itself does not change it
+	res, err = MatchHistogramGray(reference, reference)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	utils.CompareGrayImages(t, reference, res)
+
+	if _, err := MatchHistogramGray(src, image.NewGray(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Error("Expected error for an empty reference")
+	}
+}
+
+func Test_MatchHistogramGrayTo(t *testing.T) {
+	src := &image.Gray{Rect: image.Rect(0, 0, 4, 1), Stride: 4, Pix: []uint8{0, 0, 100, 255}}
+	var target [hsize]uint64
+	target[50], target[150] = 1, 3
+	res, err := MatchHistogramGrayTo(src, target)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	utils.CompareGrayImages(t, &image.Gray{Rect: src.Rect, Stride: 4, Pix: []uint8{150, 150, 150, 150}}, res)
+
+	target[150] = 1
+	res, err = MatchHistogramGrayTo(src, target)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	utils.CompareGrayImages(t, &image.Gray{Rect: src.Rect, Stride: 4, Pix: []uint8{50, 50, 150, 150}}, res)
+
+	if _, err := MatchHistogramGrayTo(src, [hsize]uint64{}); err == nil {
+		t.Error("Expected error for an empty target")
+	}
+}
+
+func Test_MatchHistogramRGBA_PerChannel(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, color.RGBA{R: 10, G: 200, B: 0, A: 255})
+	src.SetRGBA(1, 0, color.RGBA{R: 20, G: 100, B: 0, A: 128})
+	reference := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	reference.SetRGBA(0, 0, color.RGBA{R: 0, G: 30, B: 77, A: 255})
+	reference.SetRGBA(1, 0, color.RGBA{R: 255, G: 60, B: 77, A: 255})
+	res, err := MatchHistogramRGBA(src, reference, MatchPerChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	expected.SetRGBA(0, 0, color.RGBA{R: 0, G: 60, B: 77, A: 255})
+	expected.SetRGBA(1, 0, color.RGBA{R: 255, G: 30, B: 77, A: 128})
+	utils.CompareRGBAImages(t, expected, res)
+
+	if _, err := MatchHistogramRGBA(src, reference, MatchMode(42)); err == nil {
+		t.Error("Expected error for invalid match mode")
+	}
+}
+
+func Test_MatchHistogramRGBA_Luminance(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, color.RGBA{R: 90, G: 90, B: 90, A: 255})
+	src.SetRGBA(1, 0, color.RGBA{R: 120, G: 80, B: 80, A: 255})
+	reference := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	reference.SetRGBA(0, 0, color.RGBA{R: 200, G: 200, B: 200, A: 255})
+	reference.SetRGBA(1, 0, color.RGBA{R: 10, G: 10, B: 10, A: 255})
+	res, err := MatchHistogramRGBA(src, reference, MatchLuminance)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the first pixel is darker, it gets the darker luminance of the reference
+	gray, red := res.RGBAAt(0, 0), res.RGBAAt(1, 0)
+	if gray != (color.RGBA{R: 10, G: 10, B: 10, A: 255}) {
+		t.Errorf("Expected the gray pixel to become (10, 10, 10) - actual %v", gray)
+	}
+	if yy, _, _ := color.RGBToYCbCr(red.R, red.G, red.B); yy < 198 || yy > 202 || red.R <= red.G || red.G != red.B {
+		t.Errorf("Expected a red pixel with luminance 200 - actual %v", red)
+	}
+	if _, err := MatchLuminanceRGBATo(src, [hsize]uint64{}); err == nil {
+		t.Error("Expected error for an empty target")
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_MatchHistogramRGBA(t *testing.T) {
+	rgba := setupTestCaseRGBA(t)
+	path := "../res/building.jpg"
+	reference, err := imgio.ImreadRGBA(path)
+	if err != nil {
+		t.Fatalf("Could not read image from path: %s", path)
+	}
+	res, err := MatchHistogramRGBA(rgba, reference, MatchLuminance)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tearDownTestCase(t, res, "../res/histogram/matchLuminance.jpg")
+}
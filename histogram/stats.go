@@ -0,0 +1,308 @@
+package histogram
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// Stats holds the statistics of the values counted by a histogram. The values are the indices of the bins, so for
+// a 16 bit histogram with less than MaxBins16 bins they are the bins and not the original 16 bit values.
+type Stats struct {
+	// Histogram is the histogram the statistics were computed from
+	Histogram []uint64
+	// Count is the number of the counted values
+	Count uint64
+	// Min and Max are the lowest and the highest values
+	Min, Max int
+	// Mean is the average of the values
+	Mean float64
+	// Median is the 50th percentile of the values
+	Median int
+	// StdDev is the standard deviation of the values
+	StdDev float64
+	// Entropy is the Shannon entropy of the distribution of the values in bits, between 0 and log2 of the number of
+	// bins
+	Entropy float64
+}
+
+// NewStats computes the statistics of the values counted by a histogram of any number of bins, for example the one
+// returned by HistogramGray16. Every field of the result is 0 for an empty histogram.
+// Example of usage:
+//
+//	hist, err := histogram.HistogramGray16(img, 1024)
+//	stats := histogram.NewStats(hist)
+//	fmt.Println(stats.Mean, stats.Percentile(95))
+func NewStats(hist []uint64) Stats {
+	stats := Stats{Histogram: hist}
+	first := true
+	var sum float64
+	for i, bin := range hist {
+		if bin == 0 {
+			continue
+		}
+		if first {
+			stats.Min, first = i, false
+		}
+		stats.Max = i
+		stats.Count += bin
+		sum += float64(i) * float64(bin)
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+	n := float64(stats.Count)
+	stats.Mean = sum / n
+	var variance float64
+	for i, bin := range hist {
+		if bin == 0 {
+			continue
+		}
+		d := float64(i) - stats.Mean
+		variance += d * d * float64(bin)
+		p := float64(bin) / n
+		stats.Entropy -= p * math.Log2(p)
+	}
+	stats.StdDev = math.Sqrt(variance / n)
+	stats.Median = stats.Percentile(50)
+	return stats
+}
+
+// NewStats8 computes the statistics of the values counted by a 256 bin histogram, see NewStats.
+// Example of usage:
+//
+//	stats := histogram.NewStats8(histogram.HistogramGray(img))
+func NewStats8(hist [hsize]uint64) Stats {
+	return NewStats(hist[:])
+}
+
+// StatsGray computes the statistics of the pixels of a grayscale image.
+// Example of usage:
+//
+//	stats := histogram.StatsGray(img)
+func StatsGray(img *image.Gray) Stats {
+	return NewStats8(HistogramGray(img))
+}
+
+// Percentile returns the lowest value which is greater than or equal to p percent of the values (nearest rank
+// method). p is clamped to the 0..100 range, the 0th percentile is the minimum.
+// Example of usage:
+//
+//	p95 := stats.Percentile(95)
+func (s Stats) Percentile(p float64) int {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(math.Max(0, math.Min(100, p)) / 100 * float64(s.Count)))
+	if rank == 0 {
+		return s.Min
+	}
+	var cumulative uint64
+	for i, bin := range s.Histogram {
+		cumulative += bin
+		if cumulative >= rank {
+			return i
+		}
+	}
+	return s.Max
+}
+
+// CumulativeHistogram returns the cumulative version of a histogram of any number of bins: every bin is the number
+// of values up to and including its level.
+// Example of usage:
+//
+//	hist, err := histogram.HistogramGray16(img, 1024)
+//	cdf := histogram.CumulativeHistogram(hist)
+func CumulativeHistogram(hist []uint64) []uint64 {
+	res := make([]uint64, len(hist))
+	var sum uint64
+	for i, bin := range hist {
+		sum += bin
+		res[i] = sum
+	}
+	return res
+}
+
+// CumulativeHistogram8 returns the cumulative version of a 256 bin histogram, see CumulativeHistogram.
+// Example of usage:
+//
+//	cdf := histogram.CumulativeHistogram8(histogram.HistogramGray(img))
+func CumulativeHistogram8(hist [hsize]uint64) [hsize]uint64 {
+	var res [hsize]uint64
+	copy(res[:], CumulativeHistogram(hist[:]))
+	return res
+}
+
+// HistogramGrayMasked computes the histogram of the pixels of a grayscale image where the mask is non-zero. The mask
+// has to be of the same size as the image.
+// Example of usage:
+//
+//	hist, err := histogram.HistogramGrayMasked(img, mask)
+func HistogramGrayMasked(img *image.Gray, mask *image.Gray) ([hsize]uint64, error) {
+	var res [hsize]uint64
+	if err := validateMask(img.Bounds(), mask); err != nil {
+		return res, err
+	}
+	forEachMasked(img.Bounds(), mask, func(x, y int) {
+		res[img.Pix[img.PixOffset(x, y)]]++
+	})
+	return res, nil
+}
+
+// HistogramRGBAMasked computes the histograms of the red, green and blue channels of the pixels of an RGBA image where
+// the mask is non-zero. The mask has to be of the same size as the image.
+// Example of usage:
+//
+//	hist, err := histogram.HistogramRGBAMasked(img, mask)
+func HistogramRGBAMasked(img *image.RGBA, mask *image.Gray) ([channels][hsize]uint64, error) {
+	var res [channels][hsize]uint64
+	if err := validateMask(img.Bounds(), mask); err != nil {
+		return res, err
+	}
+	forEachMasked(img.Bounds(), mask, func(x, y int) {
+		i := img.PixOffset(x, y)
+		res[0][img.Pix[i]]++
+		res[1][img.Pix[i+1]]++
+		res[2][img.Pix[i+2]]++
+	})
+	return res, nil
+}
+
+// StatsGrayMasked computes the statistics of the pixels of a grayscale image where the mask is non-zero. The mask has
+// to be of the same size as the image.
+// Example of usage:
+//
+//	stats, err := histogram.StatsGrayMasked(img, mask)
+func StatsGrayMasked(img *image.Gray, mask *image.Gray) (Stats, error) {
+	hist, err := HistogramGrayMasked(img, mask)
+	if err != nil {
+		return Stats{}, err
+	}
+	return NewStats8(hist), nil
+}
+
+// CompareMethod is an enum type for the histogram comparison metrics
+type CompareMethod int
+
+const (
+	// CompareCorrelation - the Pearson correlation of the bins, 1 for identical shapes, lower is less similar
+	CompareCorrelation CompareMethod = iota
+	// CompareChiSquare - sum((h1 - h2)^2 / (h1 + h2)), 0 for identical histograms, higher is less similar
+	CompareChiSquare
+	// CompareIntersection - sum(min(h1, h2)), 1 for identical histograms, lower is less similar
+	CompareIntersection
+	// CompareBhattacharyya - sqrt(1 - sum(sqrt(h1 * h2))), 0 for identical histograms, 1 for histograms without overlap
+	CompareBhattacharyya
+	// CompareEMD - the earth mover's distance: the average number of bins the values of h1 have to be moved by to
+	// get h2, 0 for identical histograms, higher is less similar
+	CompareEMD
+)
+
+// CompareHistograms measures the similarity of two histograms of the same number of bins using one of the following
+// methods: CompareCorrelation, CompareChiSquare, CompareIntersection, CompareBhattacharyya, CompareEMD.
+// Both histograms are normalized to a sum of 1 first, so images of different sizes can be compared. The histograms
+// can not be empty.
+// Example of usage:
+//
+//	hist1, err := histogram.HistogramGray16(img1, 1024)
+//	hist2, err := histogram.HistogramGray16(img2, 1024)
+//	distance, err := histogram.CompareHistograms(hist1, hist2, histogram.CompareBhattacharyya)
+func CompareHistograms(hist1 []uint64, hist2 []uint64, method CompareMethod) (float64, error) {
+	if len(hist1) != len(hist2) {
+		return 0, errors.New("histograms must have the same number of bins")
+	}
+	if isEmpty(hist1) || isEmpty(hist2) {
+		return 0, errors.New("histograms can not be empty")
+	}
+	h1, h2 := normalizeSum(hist1), normalizeSum(hist2)
+	var res float64
+	switch method {
+	case CompareCorrelation:
+		// both means are 1/bins after the normalization
+		mean := 1 / float64(len(h1))
+		var covariance, variance1, variance2 float64
+		for i := range h1 {
+			d1, d2 := h1[i]-mean, h2[i]-mean
+			covariance += d1 * d2
+			variance1 += d1 * d1
+			variance2 += d2 * d2
+		}
+		if variance1 == 0 || variance2 == 0 {
+			// a flat histogram only correlates with itself
+			if variance1 == variance2 {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		res = covariance / math.Sqrt(variance1*variance2)
+	case CompareChiSquare:
+		for i := range h1 {
+			if sum := h1[i] + h2[i]; sum > 0 {
+				res += (h1[i] - h2[i]) * (h1[i] - h2[i]) / sum
+			}
+		}
+	case CompareIntersection:
+		for i := range h1 {
+			res += math.Min(h1[i], h2[i])
+		}
+	case CompareBhattacharyya:
+		var coefficient float64
+		for i := range h1 {
+			coefficient += math.Sqrt(h1[i] * h2[i])
+		}
+		res = math.Sqrt(math.Max(0, 1-coefficient))
+	case CompareEMD:
+		// in one dimension the earth mover's distance is the area between the cumulative distributions
+		var cdf1, cdf2 float64
+		for i := range h1 {
+			cdf1 += h1[i]
+			cdf2 += h2[i]
+			res += math.Abs(cdf1 - cdf2)
+		}
+	default:
+		return 0, errors.New("invalid compare method")
+	}
+	return res, nil
+}
+
+// CompareHistograms8 measures the similarity of two 256 bin histograms, see CompareHistograms.
+// Example of usage:
+//
+//	distance, err := histogram.CompareHistograms8(histogram.HistogramGray(img1), histogram.HistogramGray(img2),
+//		histogram.CompareBhattacharyya)
+func CompareHistograms8(hist1 [hsize]uint64, hist2 [hsize]uint64, method CompareMethod) (float64, error) {
+	return CompareHistograms(hist1[:], hist2[:], method)
+}
+
+// -------------------------------------------------------------------------------------------------------
+func normalizeSum(hist []uint64) []float64 {
+	res := make([]float64, len(hist))
+	var sum float64
+	for _, bin := range hist {
+		sum += float64(bin)
+	}
+	for i, bin := range hist {
+		res[i] = float64(bin) / sum
+	}
+	return res
+}
+
+func validateMask(bounds image.Rectangle, mask *image.Gray) error {
+	if mask.Bounds().Size() != bounds.Size() {
+		return errors.New("mask must have the same size as the image")
+	}
+	return nil
+}
+
+// forEachMasked calls f with the absolute position of every pixel of bounds where the mask is non-zero.
+func forEachMasked(bounds image.Rectangle, mask *image.Gray, f func(x, y int)) {
+	size := bounds.Size()
+	maskOffset := mask.Bounds().Min
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if mask.Pix[mask.PixOffset(x+maskOffset.X, y+maskOffset.Y)] != 0 {
+				f(x+bounds.Min.X, y+bounds.Min.Y)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package histogram
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// --------------------------------Unit tests---------------------------------------
+
+func Test_Stats(t *testing.T) {
+	img := &image.Gray{Rect: image.Rect(0, 0, 5, 1), Stride: 5, Pix: []uint8{2, 10, 1, 3, 2}}
+	stats := StatsGray(img)
+	if stats.Count != 5 || stats.Min != 1 || stats.Max != 10 || stats.Median != 2 {
+		t.Errorf("Expected count 5, min 1, max 10, median 2 - actual %+v", stats)
+	}
+	if !utils.IsEqualFloat64(stats.Mean, 3.6) || !utils.IsEqualFloat64(stats.StdDev, math.Sqrt(10.64)) {
+		t.Errorf("Expected mean 3.6, standard deviation 3.2619 - actual %f, %f", stats.Mean, stats.StdDev)
+	}
+	// the probabilities are 0.2, 0.4, 0.2, 0.2
+	if entropy := -3*0.2*math.Log2(0.2) - 0.4*math.Log2(0.4); !utils.IsEqualFloat64(stats.Entropy, entropy) {
+		t.Errorf("Expected entropy %f - actual %f", entropy, stats.Entropy)
+	}
+	percentiles := map[float64]int{-5: 1, 0: 1, 20: 1, 21: 2, 80: 3, 81: 10, 100: 10, 150: 10}
+	for p, expected := range percentiles {
+		if actual := stats.Percentile(p); actual != expected {
+			t.Errorf("Expected percentile %f to be %d - actual %d", p, expected, actual)
+		}
+	}
+
+	empty := NewStats(nil)
+	if empty.Count != 0 || empty.Max != 0 || empty.Mean != 0 || empty.Entropy != 0 || empty.Percentile(50) != 0 {
+		t.Errorf("Expected zero statistics for an empty histogram - actual %+v", empty)
+	}
+}
+
+func Test_CumulativeHistogram(t *testing.T) {
+	var hist [hsize]uint64
+	hist[0], hist[3], hist[255] = 2, 1, 4
+	cumulative := CumulativeHistogram8(hist)
+	if cumulative[0] != 2 || cumulative[2] != 2 || cumulative[3] != 3 || cumulative[254] != 3 || cumulative[255] != 7 {
+		t.Errorf("Expected the running sum of the bins - actual %v", cumulative)
+	}
+	if cumulative := CumulativeHistogram([]uint64{1, 0, 5}); len(cumulative) != 3 || cumulative[1] != 1 ||
+		cumulative[2] != 6 {
+		t.Errorf("Expected the running sum of the bins - actual %v", cumulative)
+	}
+}
+
+func Test_Stats_Gray16(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 4, 1))
+	for x, v := range []uint16{1000, 1000, 40000, 65535} {
+		img.SetGray16(x, 0, color.Gray16{Y: v})
+	}
+	hist, err := HistogramGray16(img, MaxBins16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stats := NewStats(hist)
+	if stats.Count != 4 || stats.Min != 1000 || stats.Max != 65535 || stats.Median != 1000 ||
+		!utils.IsEqualFloat64(stats.Mean, 26883.75) || !utils.IsEqualFloat64(stats.Entropy, 1.5) {
+		t.Errorf("Expected count 4, min 1000, max 65535, median 1000, mean 26883.75, entropy 1.5 - actual %+v",
+			stats)
+	}
+	if p := stats.Percentile(75); p != 40000 {
+		t.Errorf("Expected percentile 75 to be 40000 - actual %d", p)
+	}
+
+	binned, _ := HistogramGray16(img, 1024)
+	shifted := image.NewGray16(img.Bounds())
+	for x := 0; x < 4; x++ {
+		shifted.SetGray16(x, 0, color.Gray16{Y: img.Gray16At(x, 0).Y / 2})
+	}
+	binnedShifted, _ := HistogramGray16(shifted, 1024)
+	if similarity, err := CompareHistograms(binned, binned, CompareIntersection); err != nil ||
+		!utils.IsEqualFloat64(similarity, 1) {
+		t.Errorf("Expected intersection 1 for identical histograms - actual %f, %v", similarity, err)
+	}
+	if distance, err := CompareHistograms(binned, binnedShifted, CompareBhattacharyya); err != nil || distance <= 0 {
+		t.Errorf("Expected a positive distance for different histograms - actual %f, %v", distance, err)
+	}
+	if _, err := CompareHistograms(binned, hist, CompareEMD); err == nil {
+		t.Error("Expected error for histograms of different number of bins")
+	}
+}
+
+func Test_HistogramMasked(t *testing.T) {
+	img := &image.Gray{Rect: image.Rect(0, 0, 4, 1), Stride: 4, Pix: []uint8{4, 5, 6, 7}}
+	mask := &image.Gray{Rect: image.Rect(0, 0, 3, 1), Stride: 3, Pix: []uint8{0, 255, 1}}
+	hist, err := HistogramGrayMasked(img.SubImage(image.Rect(1, 0, 4, 1)).(*image.Gray), mask)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if hist[5] != 0 || hist[6] != 1 || hist[7] != 1 {
+		t.Errorf("Expected only the values 6 and 7 to be counted")
+	}
+	stats, err := StatsGrayMasked(img.SubImage(image.Rect(1, 0, 4, 1)).(*image.Gray), mask)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stats.Count != 2 || !utils.IsEqualFloat64(stats.Mean, 6.5) {
+		t.Errorf("Expected 2 values with mean 6.5 - actual %+v", stats)
+	}
+	if _, err := HistogramGrayMasked(img, mask); err == nil {
+		t.Error("Expected error for a mask of different size")
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	copy(rgba.Pix, []uint8{1, 2, 3, 255, 4, 5, 6, 255, 7, 8, 9, 255})
+	rgbHist, err := HistogramRGBAMasked(rgba, mask)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rgbHist[0][1] != 0 || rgbHist[0][4] != 1 || rgbHist[1][8] != 1 || rgbHist[2][9] != 1 || rgbHist[2][3] != 0 {
+		t.Errorf("Expected only the second and third pixels to be counted - actual %v", rgbHist)
+	}
+	if _, err := HistogramRGBAMasked(image.NewRGBA(image.Rect(0, 0, 3, 2)), mask); err == nil {
+		t.Error("Expected error for a mask of different size")
+	}
+}
+
+func Test_CompareHistograms(t *testing.T) {
+	var spike, scaled, shifted [hsize]uint64
+	spike[0], scaled[0], shifted[10] = 1, 3, 2
+	methods := []CompareMethod{CompareCorrelation, CompareChiSquare, CompareIntersection, CompareBhattacharyya,
+		CompareEMD}
+	// identical shapes, the scale does not matter
+	identical := []float64{1, 0, 1, 0, 0}
+	// no overlap, the values are 10 gray levels apart
+	disjoint := []float64{-1.0 / 255, 2, 0, 1, 10}
+	for i, method := range methods {
+		if actual, err := CompareHistograms8(spike, scaled, method); err != nil ||
+			!utils.IsEqualFloat64(actual, identical[i]) {
+			t.Errorf("Method %d: expected %f for identical histograms - actual %f, %v", method, identical[i], actual,
+				err)
+		}
+		if actual, err := CompareHistograms8(spike, shifted, method); err != nil ||
+			!utils.IsEqualFloat64(actual, disjoint[i]) {
+			t.Errorf("Method %d: expected %f for disjoint histograms - actual %f, %v", method, disjoint[i], actual,
+				err)
+		}
+	}
+
+	var half [hsize]uint64
+	half[0], half[10] = 1, 1
+	if emd, _ := CompareHistograms8(spike, half, CompareEMD); !utils.IsEqualFloat64(emd, 5) {
+		t.Errorf("Expected half of the values to be moved by 10 levels - actual %f", emd)
+	}
+	intersection, _ := CompareHistograms8(spike, half, CompareIntersection)
+	if !utils.IsEqualFloat64(intersection, 0.5) {
+		t.Errorf("Expected intersection 0.5 - actual %f", intersection)
+	}
+
+	if _, err := CompareHistograms8(spike, [hsize]uint64{}, CompareEMD); err == nil {
+		t.Error("Expected error for an empty histogram")
+	}
+	if _, err := CompareHistograms8(spike, scaled, CompareMethod(42)); err == nil {
+		t.Error("Expected error for invalid compare method")
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_CompareHistograms(t *testing.T) {
+	girl := HistogramGray(setupTestCaseGray(t))
+	equalized := HistogramGray(EqualizeGray(setupTestCaseGray(t)))
+	similar, err := CompareHistograms8(girl, girl, CompareBhattacharyya)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	different, err := CompareHistograms8(girl, equalized, CompareBhattacharyya)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if similar >= different {
+		t.Errorf("Expected an image to be closer to itself (%f) than to its equalized version (%f)", similar, different)
+	}
+}
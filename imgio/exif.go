@@ -0,0 +1,362 @@
+package imgio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Orientation is the value of the EXIF Orientation tag. It describes how the stored pixels have to be transformed to
+// be displayed upright.
+type Orientation int
+
+const (
+	// OrientationUnknown - the image carries no orientation information, it is handled as OrientationNormal
+	OrientationUnknown Orientation = iota
+	// OrientationNormal - the stored pixels are upright
+	OrientationNormal
+	// OrientationFlipH - the stored pixels are mirrored horizontally
+	OrientationFlipH
+	// OrientationRotate180 - the stored pixels are rotated by 180 degrees
+	OrientationRotate180
+	// OrientationFlipV - the stored pixels are mirrored vertically
+	OrientationFlipV
+	// OrientationTranspose - the stored pixels are mirrored along the top-left to bottom-right diagonal
+	OrientationTranspose
+	// OrientationRotate90 - the stored pixels have to be rotated 90 degrees clockwise to be displayed upright
+	OrientationRotate90
+	// OrientationTransverse - the stored pixels are mirrored along the top-right to bottom-left diagonal
+	OrientationTransverse
+	// OrientationRotate270 - the stored pixels have to be rotated 90 degrees counterclockwise to be displayed upright
+	OrientationRotate270
+)
+
+// Metadata holds the information read from the header of a JPEG image.
+type Metadata struct {
+	// Width is the width of the stored image in pixels, before any orientation is applied
+	Width int
+	// Height is the height of the stored image in pixels, before any orientation is applied
+	Height int
+	// Orientation is the EXIF orientation of the image
+	Orientation Orientation
+	// DPIX is the horizontal resolution in dots per inch, 0 if unknown
+	DPIX float64
+	// DPIY is the vertical resolution in dots per inch, 0 if unknown
+	DPIY float64
+	// CaptureTime is the time when the image was taken, zero if unknown. EXIF timestamps without an offset are
+	// returned in UTC.
+	CaptureTime time.Time
+}
+
+// maxJPEGHeaderSize is the number of bytes inspected when looking for the metadata of a JPEG image. It fits a full
+// APP0 and APP1 segment.
+const maxJPEGHeaderSize = 1 << 17
+
+const (
+	tagOrientation        = 0x0112
+	tagXResolution        = 0x011A
+	tagYResolution        = 0x011B
+	tagResolutionUnit     = 0x0128
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+)
+
+// ReadMetadata reads the metadata (dimensions, EXIF orientation, resolution and capture time) of a JPEG image from r.
+// Only the header of the image is read. Returns an error if r does not contain a JPEG image.
+func ReadMetadata(r io.Reader) (*Metadata, error) {
+	br := bufio.NewReaderSize(r, maxJPEGHeaderSize)
+	f, err := sniff(br)
+	if err != nil {
+		return nil, err
+	}
+	if f.Name != "jpeg" {
+		return nil, errors.New("metadata is only supported for jpeg images")
+	}
+	header, _ := br.Peek(maxJPEGHeaderSize)
+	return parseJPEGMetadata(header)
+}
+
+// ImreadMetadata reads the metadata of the JPEG image from the given path. See ReadMetadata.
+func ImreadMetadata(path string) (*Metadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadMetadata(file)
+}
+
+// -------------------------------------------------------------------------------------------------------
+func parseJPEGMetadata(b []byte) (*Metadata, error) {
+	if len(b) < 2 || b[0] != 0xFF || b[1] != 0xD8 {
+		return nil, errors.New("invalid jpeg header")
+	}
+	m := &Metadata{}
+	var exif []byte
+	i := 2
+	for i+4 <= len(b) {
+		if b[i] != 0xFF {
+			return nil, errors.New("invalid jpeg marker")
+		}
+		marker := b[i+1]
+		if marker == 0xFF {
+			// fill byte
+			i++
+			continue
+		}
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+			i += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(b[i+2:]))
+		if length < 2 {
+			return nil, errors.New("invalid jpeg segment length")
+		}
+		end := i + 2 + length
+		segment := b[i+4 : minInt(end, len(b))]
+		switch {
+		case marker == 0xE0 && bytes.HasPrefix(segment, []byte("JFIF\x00")) && len(segment) >= 12:
+			if m.DPIX == 0 {
+				m.DPIX, m.DPIY = jfifDensity(segment[7], binary.BigEndian.Uint16(segment[8:]),
+					binary.BigEndian.Uint16(segment[10:]))
+			}
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) && exif == nil:
+			exif = segment[6:]
+		case isSOFMarker(marker) && len(segment) >= 5:
+			m.Height = int(binary.BigEndian.Uint16(segment[1:]))
+			m.Width = int(binary.BigEndian.Uint16(segment[3:]))
+			if exif != nil {
+				parseExif(exif, m)
+			}
+			return m, nil
+		case marker == 0xDA:
+			// start of scan without a frame header
+			return nil, errors.New("missing jpeg frame header")
+		}
+		i = end
+	}
+	return nil, errors.New("jpeg frame header not found")
+}
+
+func isSOFMarker(marker byte) bool {
+	return marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC
+}
+
+func jfifDensity(units uint8, x uint16, y uint16) (float64, float64) {
+	switch units {
+	case 1:
+		return float64(x), float64(y)
+	case 2:
+		return float64(x) * 2.54, float64(y) * 2.54
+	}
+	return 0, 0
+}
+
+// tiffReader reads the values of a TIFF structure, which is the container format of EXIF data.
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// parseExif fills m from the EXIF data. Malformed or unknown entries are skipped, a broken EXIF block never prevents
+// the image from being read.
+func parseExif(data []byte, m *Metadata) {
+	if len(data) < 8 {
+		return
+	}
+	t := tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return
+	}
+	ifd0 := t.readIFD(t.order.Uint32(data[4:]))
+	unit := uint16(2)
+	var xRes, yRes float64
+	var dateTime, dateTimeOriginal, offsetTimeOriginal string
+	for _, e := range ifd0 {
+		switch e.tag {
+		case tagOrientation:
+			if o := Orientation(t.uint(e)); o >= OrientationNormal && o <= OrientationRotate270 {
+				m.Orientation = o
+			}
+		case tagXResolution:
+			xRes = t.rational(e)
+		case tagYResolution:
+			yRes = t.rational(e)
+		case tagResolutionUnit:
+			unit = uint16(t.uint(e))
+		case tagDateTime:
+			dateTime = e.asciiValue()
+		case tagExifIFD:
+			for _, sub := range t.readIFD(t.uint(e)) {
+				switch sub.tag {
+				case tagDateTimeOriginal:
+					dateTimeOriginal = sub.asciiValue()
+				case tagOffsetTimeOriginal:
+					offsetTimeOriginal = sub.asciiValue()
+				}
+			}
+		}
+	}
+	if xRes > 0 && yRes > 0 {
+		switch unit {
+		case 2:
+			m.DPIX, m.DPIY = xRes, yRes
+		case 3:
+			m.DPIX, m.DPIY = xRes*2.54, yRes*2.54
+		}
+	}
+	if dateTimeOriginal != "" {
+		m.CaptureTime = parseExifTime(dateTimeOriginal, offsetTimeOriginal)
+	} else if dateTime != "" {
+		m.CaptureTime = parseExifTime(dateTime, "")
+	}
+}
+
+func (t tiffReader) readIFD(offset uint32) []ifdEntry {
+	if int64(offset)+2 > int64(len(t.data)) {
+		return nil
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	entries := make([]ifdEntry, 0, count)
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(t.data) {
+			break
+		}
+		raw := t.data[start : start+12]
+		e := ifdEntry{tag: t.order.Uint16(raw), typ: t.order.Uint16(raw[2:]), count: t.order.Uint32(raw[4:])}
+		size := int64(typeSize(e.typ)) * int64(e.count)
+		if size <= 4 {
+			e.value = raw[8 : 8+size]
+		} else {
+			valueOffset := int64(t.order.Uint32(raw[8:]))
+			if valueOffset+size > int64(len(t.data)) {
+				continue
+			}
+			e.value = t.data[valueOffset : valueOffset+size]
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	}
+	return 0
+}
+
+func (t tiffReader) uint(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(t.order.Uint16(e.value))
+	case e.typ == 4 && len(e.value) >= 4:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+func (t tiffReader) rational(e ifdEntry) float64 {
+	if e.typ != 5 || len(e.value) < 8 {
+		return 0
+	}
+	den := t.order.Uint32(e.value[4:])
+	if den == 0 {
+		return 0
+	}
+	return float64(t.order.Uint32(e.value)) / float64(den)
+}
+
+func (e ifdEntry) asciiValue() string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(e.value), "\x00 ")
+}
+
+func parseExifTime(value string, offset string) time.Time {
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", value+offset); err == nil {
+			return t
+		}
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// orientPix transforms the pixel buffer of an image whose bounds start at (0, 0) so that an image stored with the given
+// orientation becomes upright. bpp is the number of bytes per pixel. Returns the new pixel buffer, stride and bounds.
+func orientPix(pix []uint8, stride int, rect image.Rectangle, bpp int, o Orientation) ([]uint8, int, image.Rectangle) {
+	w, h := rect.Dx(), rect.Dy()
+	var src func(x, y int) (int, int)
+	newW, newH := w, h
+	switch o {
+	case OrientationFlipH:
+		src = func(x, y int) (int, int) { return w - 1 - x, y }
+	case OrientationRotate180:
+		src = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case OrientationFlipV:
+		src = func(x, y int) (int, int) { return x, h - 1 - y }
+	case OrientationTranspose:
+		src = func(x, y int) (int, int) { return y, x }
+	case OrientationRotate90:
+		src = func(x, y int) (int, int) { return y, h - 1 - x }
+	case OrientationTransverse:
+		src = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case OrientationRotate270:
+		src = func(x, y int) (int, int) { return w - 1 - y, x }
+	default:
+		return pix, stride, rect
+	}
+	if o >= OrientationTranspose {
+		newW, newH = h, w
+	}
+	newStride := newW * bpp
+	res := make([]uint8, newStride*newH)
+	for y := 0; y < newH; y++ {
+		for x := 0; x < newW; x++ {
+			sx, sy := src(x, y)
+			srcOffset := sy*stride + sx*bpp
+			copy(res[y*newStride+x*bpp:y*newStride+(x+1)*bpp], pix[srcOffset:srcOffset+bpp])
+		}
+	}
+	return res, newStride, image.Rect(0, 0, newW, newH)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
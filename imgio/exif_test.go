@@ -0,0 +1,161 @@
+package imgio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+)
+
+// buildExif creates a big endian EXIF block with orientation, a 300 DPI resolution and a capture time.
+func buildExif(orientation uint16) []byte {
+	const ifd0Entries = 5
+	ifd0Size := 2 + ifd0Entries*12 + 4
+	xResOffset := 8 + ifd0Size
+	yResOffset := xResOffset + 8
+	exifIFDOffset := yResOffset + 8
+	exifIFDSize := 2 + 12 + 4
+	dateOffset := exifIFDOffset + exifIFDSize
+
+	var b bytes.Buffer
+	be := binary.BigEndian
+	write := func(v interface{}) { _ = binary.Write(&b, be, v) }
+	entry := func(tag uint16, typ uint16, count uint32, value uint32) {
+		write(tag)
+		write(typ)
+		write(count)
+		if typ == 3 && count == 1 {
+			write(uint16(value))
+			write(uint16(0))
+		} else {
+			write(value)
+		}
+	}
+	b.WriteString("MM")
+	write(uint16(0x2A))
+	write(uint32(8))
+	write(uint16(ifd0Entries))
+	entry(tagOrientation, 3, 1, uint32(orientation))
+	entry(tagXResolution, 5, 1, uint32(xResOffset))
+	entry(tagYResolution, 5, 1, uint32(yResOffset))
+	entry(tagResolutionUnit, 3, 1, 2)
+	entry(tagExifIFD, 4, 1, uint32(exifIFDOffset))
+	write(uint32(0))
+	write([]uint32{300, 1})
+	write([]uint32{300, 1})
+	write(uint16(1))
+	entry(tagDateTimeOriginal, 2, 20, uint32(dateOffset))
+	write(uint32(0))
+	b.WriteString("2021:07:15 10:20:30\x00")
+	return b.Bytes()
+}
+
+// jpegWithExif encodes a 16x8 image, whose left half is black and right half is white, and inserts an APP1 segment
+// holding the EXIF block right after the SOI marker.
+func jpegWithExif(t *testing.T, orientation uint16) []byte {
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 8; x < 16; x++ {
+			img.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	payload := append([]byte("Exif\x00\x00"), buildExif(orientation)...)
+	var out bytes.Buffer
+	out.Write(encoded.Bytes()[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(encoded.Bytes()[2:])
+	return out.Bytes()
+}
+
+// ---------------------------------Unit tests------------------------------------
+func Test_ReadMetadata(t *testing.T) {
+	data := jpegWithExif(t, uint16(OrientationRotate90))
+	m, err := ReadMetadata(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width != 16 || m.Height != 8 {
+		t.Errorf("Expected size 16x8 - actual size %dx%d", m.Width, m.Height)
+	}
+	if m.Orientation != OrientationRotate90 {
+		t.Errorf("Expected orientation %d - actual orientation %d", OrientationRotate90, m.Orientation)
+	}
+	if m.DPIX != 300 || m.DPIY != 300 {
+		t.Errorf("Expected 300x300 DPI - actual %vx%v DPI", m.DPIX, m.DPIY)
+	}
+	expectedTime := time.Date(2021, 7, 15, 10, 20, 30, 0, time.UTC)
+	if !m.CaptureTime.Equal(expectedTime) {
+		t.Errorf("Expected capture time %v - actual capture time %v", expectedTime, m.CaptureTime)
+	}
+}
+
+func Test_ReadMetadata_NoExif(t *testing.T) {
+	m, err := ImreadMetadata("../res/girl.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width != 403 || m.Height != 403 {
+		t.Errorf("Expected size 403x403 - actual size %dx%d", m.Width, m.Height)
+	}
+	if _, err := ImreadMetadata("../res/engine.png"); err == nil {
+		t.Error("Expected an error for a png image")
+	}
+}
+
+func Test_DecodeGrayWithOptions_AutoOrient(t *testing.T) {
+	data := jpegWithExif(t, uint16(OrientationRotate90))
+
+	stored, err := DecodeGray(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Bounds().Size() != (image.Point{X: 16, Y: 8}) {
+		t.Errorf("Expected the stored size without auto orientation, actual: %v", stored.Bounds().Size())
+	}
+
+	upright, err := DecodeGrayWithOptions(bytes.NewReader(data), &DecodeOptions{AutoOrient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upright.Bounds().Size() != (image.Point{X: 8, Y: 16}) {
+		t.Fatalf("Expected size 8x16 - actual size %v", upright.Bounds().Size())
+	}
+	// rotating clockwise moves the black left half to the top
+	if upright.GrayAt(4, 2).Y > 0x20 || upright.GrayAt(4, 13).Y < 0xE0 {
+		t.Errorf("Unexpected pixels after orientation: top %d, bottom %d", upright.GrayAt(4, 2).Y,
+			upright.GrayAt(4, 13).Y)
+	}
+}
+
+func Test_OrientPix(t *testing.T) {
+	// 3x2 image:
+	// 1 2 3
+	// 4 5 6
+	pix := []uint8{1, 2, 3, 4, 5, 6}
+	rect := image.Rect(0, 0, 3, 2)
+	expected := map[Orientation][]uint8{
+		OrientationNormal:     {1, 2, 3, 4, 5, 6},
+		OrientationFlipH:      {3, 2, 1, 6, 5, 4},
+		OrientationRotate180:  {6, 5, 4, 3, 2, 1},
+		OrientationFlipV:      {4, 5, 6, 1, 2, 3},
+		OrientationTranspose:  {1, 4, 2, 5, 3, 6},
+		OrientationRotate90:   {4, 1, 5, 2, 6, 3},
+		OrientationTransverse: {6, 3, 5, 2, 4, 1},
+		OrientationRotate270:  {3, 6, 2, 5, 1, 4},
+	}
+	for o, exp := range expected {
+		actual, _, _ := orientPix(pix, 3, rect, 1, o)
+		if !bytes.Equal(exp, actual) {
+			t.Errorf("Orientation %d: expected %v - actual %v", o, exp, actual)
+		}
+	}
+}
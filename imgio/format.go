@@ -0,0 +1,159 @@
+package imgio
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Format describes an image format known by the imgio package. The format is detected either by its magic bytes when
+// decoding from a reader, or by its file extensions when writing to a path.
+type Format struct {
+	// Name is the name of the format, for example "png" or "jpeg"
+	Name string
+	// Extensions lists the file extensions (including the leading dot) associated with the format
+	Extensions []string
+	// Magic lists the magic prefixes which identify the format. Each "?" in a prefix matches any one byte.
+	Magic []string
+	// Decode decodes an image from a reader
+	Decode func(io.Reader) (image.Image, error)
+	// Encode encodes an image to a writer. Formats which can only be read may leave it nil.
+	Encode func(io.Writer, image.Image, *EncodeOptions) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   []Format
+)
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "jpeg",
+		Extensions: []string{".jpg", ".jpeg"},
+		Magic:      []string{"\xff\xd8"},
+		Decode:     jpeg.Decode,
+		Encode:     encodeJPEG,
+	})
+	RegisterFormat(Format{
+		Name:       "png",
+		Extensions: []string{".png"},
+		Magic:      []string{"\x89PNG\r\n\x1a\n"},
+		Decode:     png.Decode,
+		Encode:     encodePNG,
+	})
+	RegisterFormat(Format{
+		Name:       "pbm",
+		Extensions: []string{".pbm"},
+		Magic:      []string{"P1", "P4"},
+		Decode:     decodeNetpbm,
+		Encode:     encodePBM,
+	})
+	RegisterFormat(Format{
+		Name:       "pgm",
+		Extensions: []string{".pgm"},
+		Magic:      []string{"P2", "P5"},
+		Decode:     decodeNetpbm,
+		Encode:     encodePGM,
+	})
+	RegisterFormat(Format{
+		Name:       "ppm",
+		Extensions: []string{".ppm"},
+		Magic:      []string{"P3", "P6"},
+		Decode:     decodeNetpbm,
+		Encode:     encodePPM,
+	})
+	RegisterFormat(Format{
+		Name:       "pam",
+		Extensions: []string{".pam"},
+		Magic:      []string{"P7"},
+		Decode:     decodeNetpbm,
+		Encode:     encodePAM,
+	})
+}
+
+// RegisterFormat registers an image format for use by the Decode* and Imread* functions. Registering a format with a
+// name which is already in use replaces the previous registration.
+func RegisterFormat(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for i := range formats {
+		if formats[i].Name == f.Name {
+			formats[i] = f
+			return
+		}
+	}
+	formats = append(formats, f)
+}
+
+// -------------------------------------------------------------------------------------------------------
+func match(magic string, b []byte) bool {
+	if len(magic) != len(b) {
+		return false
+	}
+	for i, c := range b {
+		if magic[i] != c && magic[i] != '?' {
+			return false
+		}
+	}
+	return true
+}
+
+func sniff(r *bufio.Reader) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, f := range formats {
+		for _, magic := range f.Magic {
+			b, err := r.Peek(len(magic))
+			if err == nil && match(magic, b) {
+				return f, nil
+			}
+		}
+	}
+	return Format{}, errors.New("unknown image format")
+}
+
+func formatByName(name string) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, f := range formats {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return Format{}, errors.New("unknown image format")
+}
+
+func formatByExtension(ext string) (Format, error) {
+	ext = strings.ToLower(ext)
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f, nil
+			}
+		}
+	}
+	return Format{}, errors.New("unsupported extension")
+}
+
+func encodeJPEG(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	quality := jpeg.DefaultQuality
+	if opts.JPEGQuality != 0 {
+		quality = opts.JPEGQuality
+	}
+	if quality < 1 || quality > 100 {
+		return errors.New("jpeg quality should be between 1 and 100")
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+func encodePNG(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	encoder := png.Encoder{CompressionLevel: opts.PNGCompression}
+	return encoder.Encode(w, img)
+}
@@ -1,107 +1,284 @@
 package imgio
 
 import (
+	"bufio"
 	"errors"
 	"image"
 	"image/draw"
-	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 )
 
-// Reads and decodes image from a given path. Supported extensions are: jpg, jpeg, png
-func decode(path string) (image.Image, error) {
-	file, err := os.Open(path)
-	defer file.Close()
+// DecodeOptions holds the settings used when an image is decoded.
+type DecodeOptions struct {
+	// AutoOrient applies the flip or rotation described by the EXIF Orientation tag of JPEG images, so the returned
+	// pixels are upright.
+	AutoOrient bool
+}
+
+// Decode reads an image from r. The format of the image is detected by sniffing its magic bytes, the file name or
+// extension plays no role. Returns the decoded image and the name of the detected format.
+func Decode(r io.Reader) (image.Image, string, error) {
+	img, f, _, err := decode(r, nil)
+	return img, f, err
+}
+
+// DecodeGray reads an image from r and returns it as a grayscale image. See Decode for the format detection.
+func DecodeGray(r io.Reader) (*image.Gray, error) {
+	return DecodeGrayWithOptions(r, nil)
+}
+
+// DecodeGrayWithOptions reads an image from r using the given decoder settings and returns it as a grayscale image.
+func DecodeGrayWithOptions(r io.Reader, opts *DecodeOptions) (*image.Gray, error) {
+	img, _, o, err := decode(r, opts)
 	if err != nil {
 		return nil, err
 	}
-	extension := filepath.Ext(path)
-	switch extension {
-	case ".jpg":
-		fallthrough
-	case ".jpeg":
-		return jpeg.Decode(file)
-	case ".png":
-		return png.Decode(file)
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	gray.Pix, gray.Stride, gray.Rect = orientPix(gray.Pix, gray.Stride, gray.Rect, 1, o)
+	return gray, nil
+}
+
+// DecodeGray16 reads an image from r and returns it as a grayscale16 image. See Decode for the format detection.
+func DecodeGray16(r io.Reader) (*image.Gray16, error) {
+	return DecodeGray16WithOptions(r, nil)
+}
+
+// DecodeGray16WithOptions reads an image from r using the given decoder settings and returns it as a grayscale16
+// image.
+func DecodeGray16WithOptions(r io.Reader, opts *DecodeOptions) (*image.Gray16, error) {
+	img, _, o, err := decode(r, opts)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("unsupported extension")
+	bounds := img.Bounds()
+	gray16 := image.NewGray16(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray16, gray16.Bounds(), img, bounds.Min, draw.Src)
+	gray16.Pix, gray16.Stride, gray16.Rect = orientPix(gray16.Pix, gray16.Stride, gray16.Rect, 2, o)
+	return gray16, nil
 }
 
-// Encodes and writes image to the given path
-func encode(img image.Image, path string) error {
-	file, err := os.Create(path)
+// DecodeRGBA reads an image from r and returns it as an RGBA image. See Decode for the format detection.
+func DecodeRGBA(r io.Reader) (*image.RGBA, error) {
+	return DecodeRGBAWithOptions(r, nil)
+}
+
+// DecodeRGBAWithOptions reads an image from r using the given decoder settings and returns it as an RGBA image.
+func DecodeRGBAWithOptions(r io.Reader, opts *DecodeOptions) (*image.RGBA, error) {
+	img, _, o, err := decode(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	rgba.Pix, rgba.Stride, rgba.Rect = orientPix(rgba.Pix, rgba.Stride, rgba.Rect, 4, o)
+	return rgba, nil
+}
+
+// DecodeRGBA64 reads an image from r and returns it as an RGBA64 image. See Decode for the format detection.
+func DecodeRGBA64(r io.Reader) (*image.RGBA64, error) {
+	return DecodeRGBA64WithOptions(r, nil)
+}
+
+// DecodeRGBA64WithOptions reads an image from r using the given decoder settings and returns it as an RGBA64 image.
+func DecodeRGBA64WithOptions(r io.Reader, opts *DecodeOptions) (*image.RGBA64, error) {
+	img, _, o, err := decode(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	rgba64 := image.NewRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba64, rgba64.Bounds(), img, bounds.Min, draw.Src)
+	rgba64.Pix, rgba64.Stride, rgba64.Rect = orientPix(rgba64.Pix, rgba64.Stride, rgba64.Rect, 8, o)
+	return rgba64, nil
+}
+
+// decode sniffs the format and decodes the image. If auto orientation is requested, the EXIF orientation of JPEG
+// images is returned as well, otherwise the orientation is OrientationUnknown.
+func decode(r io.Reader, opts *DecodeOptions) (image.Image, string, Orientation, error) {
+	autoOrient := opts != nil && opts.AutoOrient
+	size := 4096
+	if autoOrient {
+		size = maxJPEGHeaderSize
+	}
+	br := bufio.NewReaderSize(r, size)
+	f, err := sniff(br)
+	if err != nil {
+		return nil, "", OrientationUnknown, err
+	}
+	o := OrientationUnknown
+	if autoOrient && f.Name == "jpeg" {
+		header, _ := br.Peek(maxJPEGHeaderSize)
+		if m, err := parseJPEGMetadata(header); err == nil {
+			o = m.Orientation
+		}
+	}
+	img, err := f.Decode(br)
+	if err != nil {
+		return nil, f.Name, OrientationUnknown, err
+	}
+	return img, f.Name, o, nil
+}
+
+// EncodeOptions holds the settings used when an image is encoded.
+type EncodeOptions struct {
+	// Format is the name of the output format, for example "png" or "jpeg". When writing to a path it may be left
+	// empty, in which case the format is chosen from the file extension.
+	Format string
+	// JPEGQuality is the quality of JPEG output, ranging from 1 to 100. 0 means jpeg.DefaultQuality.
+	JPEGQuality int
+	// PNGCompression is the compression level of PNG output. The zero value is png.DefaultCompression.
+	PNGCompression png.CompressionLevel
+	// NetpbmPlain selects the plain (ASCII) variant of the PBM, PGM and PPM formats instead of the binary one.
+	NetpbmPlain bool
+}
+
+// Encode encodes an image to w using the format and settings from opts. Returns an error if opts does not specify a
+// registered output format.
+// Example of usage:
+//
+//	err := imgio.Encode(w, img, &imgio.EncodeOptions{Format: "jpeg", JPEGQuality: 85})
+func Encode(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	if opts == nil || opts.Format == "" {
+		return errors.New("output format is not specified")
+	}
+	f, err := formatByName(opts.Format)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	extension := filepath.Ext(path)
-	switch extension {
-	case ".jpg":
-		fallthrough
-	case ".jpeg":
-		return jpeg.Encode(file, img, nil)
-	case ".png":
-		return png.Encode(file, img)
+	return encode(w, img, f, opts)
+}
+
+func encode(w io.Writer, img image.Image, f Format, opts *EncodeOptions) error {
+	if f.Encode == nil {
+		return errors.New("encoding is not supported for format " + f.Name)
 	}
-	return errors.New("unsupported extension")
+	return f.Encode(w, img, opts)
 }
 
 // ImreadGray reads the image from the given path and return a grayscale image. Returns an error if the path is not
 // readable or the specified resource does not exist.
 func ImreadGray(path string) (*image.Gray, error) {
-	img, err := decode(path)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	bounds := img.Bounds()
-	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
-	draw.Draw(gray, bounds, img, bounds.Min, draw.Src)
-	return gray, nil
+	defer file.Close()
+	return DecodeGray(file)
+}
+
+// ImreadGrayWithOptions reads the image from the given path using the given decoder settings and returns a
+// grayscale image. Returns an error if the path is not readable or the specified resource does not exist.
+func ImreadGrayWithOptions(path string, opts *DecodeOptions) (*image.Gray, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeGrayWithOptions(file, opts)
 }
 
 // ImreadGray16 reads the image from the given path and return a grayscale16 image. Returns an error if the path is not
 // readable or the specified resource does not exist.
 func ImreadGray16(path string) (*image.Gray16, error) {
-	img, err := decode(path)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	bounds := img.Bounds()
-	gray16 := image.NewGray16(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
-	draw.Draw(gray16, bounds, img, bounds.Min, draw.Src)
-	return gray16, nil
+	defer file.Close()
+	return DecodeGray16(file)
+}
+
+// ImreadGray16WithOptions reads the image from the given path using the given decoder settings and returns a
+// grayscale16 image. Returns an error if the path is not readable or the specified resource does not exist.
+func ImreadGray16WithOptions(path string, opts *DecodeOptions) (*image.Gray16, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeGray16WithOptions(file, opts)
 }
 
 // ImreadRGBA reads the image from the given path and return a RGBA image. Returns an error if the path is not readable
 // or the specified resource does not exist.
 func ImreadRGBA(path string) (*image.RGBA, error) {
-	img, err := decode(path)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	bounds := img.Bounds()
-	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
-	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
-	return rgba, nil
+	defer file.Close()
+	return DecodeRGBA(file)
+}
+
+// ImreadRGBAWithOptions reads the image from the given path using the given decoder settings and returns a
+// RGBA image. Returns an error if the path is not readable or the specified resource does not exist.
+func ImreadRGBAWithOptions(path string, opts *DecodeOptions) (*image.RGBA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeRGBAWithOptions(file, opts)
 }
 
 // ImreadRGBA64 reads the image from the given path and return a RGBA64 image.
 // Returns an error if the path is not readable or the specified resource does not exist.
 func ImreadRGBA64(path string) (*image.RGBA64, error) {
-	img, err := decode(path)
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	bounds := img.Bounds()
-	rgba64 := image.NewRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
-	draw.Draw(rgba64, bounds, img, bounds.Min, draw.Src)
-	return rgba64, nil
+	defer file.Close()
+	return DecodeRGBA64(file)
 }
 
-// Imwrite saves the image under the location specified by the "path" string. Returns an error if the location is
-// not writable.
+// ImreadRGBA64WithOptions reads the image from the given path using the given decoder settings and returns a
+// RGBA64 image. Returns an error if the path is not readable or the specified resource does not exist.
+func ImreadRGBA64WithOptions(path string, opts *DecodeOptions) (*image.RGBA64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeRGBA64WithOptions(file, opts)
+}
+
+// Imwrite saves the image under the location specified by the "path" string using the default encoder settings. The
+// format is chosen from the file extension. Returns an error if the location is not writable.
 func Imwrite(img image.Image, path string) error {
-	return encode(img, path)
+	return ImwriteWithOptions(img, path, nil)
+}
+
+// ImwriteWithOptions saves the image under the location specified by the "path" string using the given encoder
+// settings. If opts does not specify a format, it is chosen from the file extension. Returns an error if the location
+// is not writable.
+func ImwriteWithOptions(img image.Image, path string, opts *EncodeOptions) error {
+	if opts == nil {
+		opts = &EncodeOptions{}
+	}
+	var f Format
+	var err error
+	if opts.Format != "" {
+		f, err = formatByName(opts.Format)
+	} else {
+		f, err = formatByExtension(filepath.Ext(path))
+	}
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encode(file, img, f, opts); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
@@ -1,9 +1,15 @@
 package imgio
 
 import (
+	"bytes"
 	"image"
+	"image/png"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/ernyoke/imger/utils"
 )
 
 // -----------------------------Acceptance tests------------------------------------
@@ -116,3 +122,116 @@ func Test_Imwrite_InvalidExtension(t *testing.T) {
 	}
 	t.Fatal("Should not reach this point!")
 }
+
+func Test_DecodeRGBA_Reader(t *testing.T) {
+	data, err := os.ReadFile("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	img, err := DecodeRGBA(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Could not decode image: %s", err)
+	}
+	expected, err := ImreadRGBA("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	utils.CompareRGBAImages(t, expected, img)
+}
+
+func Test_Decode_SniffsFormat(t *testing.T) {
+	data, err := os.ReadFile("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	// a PNG saved with a misleading, upper-case JPEG extension
+	path := filepath.Join(t.TempDir(), "engine.JPG")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImreadGray(path); err != nil {
+		t.Fatalf("Could not read file with misleading extension: %s", err)
+	}
+	_, name, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "png" {
+		t.Errorf("Expected format: png - actual format: %s", name)
+	}
+}
+
+func Test_Decode_UnknownFormat(t *testing.T) {
+	_, err := DecodeGray(strings.NewReader("definitely not an image"))
+	if err != nil {
+		// ok
+		return
+	}
+	t.Fatal("Should not reach this point!")
+}
+
+func Test_Encode_JPEGQuality(t *testing.T) {
+	img, err := ImreadRGBA("../res/girl.jpg")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	var low, high bytes.Buffer
+	if err := Encode(&low, img, &EncodeOptions{Format: "jpeg", JPEGQuality: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Encode(&high, img, &EncodeOptions{Format: "jpeg", JPEGQuality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	if low.Len() >= high.Len() {
+		t.Errorf("Expected quality 10 output (%d bytes) to be smaller then quality 95 output (%d bytes)",
+			low.Len(), high.Len())
+	}
+	decoded, err := DecodeRGBA(&high)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Bounds().Size().Eq(img.Bounds().Size()) {
+		t.Errorf("Expected size %v - actual size %v", img.Bounds().Size(), decoded.Bounds().Size())
+	}
+}
+
+func Test_Encode_PNGCompression(t *testing.T) {
+	img, err := ImreadGray("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, &EncodeOptions{Format: "png", PNGCompression: png.BestSpeed}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeGray(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImages(t, img, decoded)
+}
+
+func Test_Encode_MissingFormat(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, nil); err == nil {
+		t.Error("Expected an error for missing format")
+	}
+	if err := Encode(&buf, img, &EncodeOptions{Format: "xxx"}); err == nil {
+		t.Error("Expected an error for unknown format")
+	}
+	if err := Encode(&buf, img, &EncodeOptions{Format: "jpeg", JPEGQuality: 101}); err == nil {
+		t.Error("Expected an error for invalid jpeg quality")
+	}
+}
+
+func Test_ImwriteWithOptions_ExplicitFormat(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	path := filepath.Join(t.TempDir(), "noextension")
+	if err := ImwriteWithOptions(img, path, &EncodeOptions{Format: "png"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImreadGray(path); err != nil {
+		t.Fatalf("Could not read back file: %s", err)
+	}
+}
@@ -0,0 +1,487 @@
+package imgio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// Netpbm support: PBM (P1, P4), PGM (P2, P5), PPM (P3, P6) and PAM (P7).
+// More info: https://netpbm.sourceforge.net/doc/
+
+// MaxNetpbmImageSize is the maximum size in bytes of the pixel data of a decoded Netpbm image. The size declared by the
+// header is checked before the image is allocated, so a few bytes of input can not exhaust the memory.
+const MaxNetpbmImageSize = 1 << 28
+
+// netpbmHeader holds the values parsed from the header of a Netpbm file.
+type netpbmHeader struct {
+	magic  byte
+	width  int
+	height int
+	depth  int
+	maxVal int
+	alpha  bool
+}
+
+func decodeNetpbm(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readNetpbmHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	switch h.magic {
+	case '1':
+		return readPlainPBM(br, h)
+	case '4':
+		return readBinaryPBM(br, h)
+	}
+	return readNetpbmSamples(br, h)
+}
+
+func readNetpbmHeader(r *bufio.Reader) (netpbmHeader, error) {
+	var h netpbmHeader
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return h, err
+	}
+	if magic[0] != 'P' || magic[1] < '1' || magic[1] > '7' {
+		return h, errors.New("invalid netpbm magic number")
+	}
+	h.magic = magic[1]
+	if h.magic == '7' {
+		return readPAMHeader(r, h)
+	}
+
+	var err error
+	if h.width, err = readNetpbmInt(r); err != nil {
+		return h, err
+	}
+	if h.height, err = readNetpbmInt(r); err != nil {
+		return h, err
+	}
+	switch h.magic {
+	case '1', '4':
+		h.depth, h.maxVal = 1, 1
+	case '2', '5':
+		h.depth = 1
+	case '3', '6':
+		h.depth = 3
+	}
+	if h.maxVal == 0 {
+		if h.maxVal, err = readNetpbmInt(r); err != nil {
+			return h, err
+		}
+	}
+	return h, validateNetpbmHeader(h)
+}
+
+func readPAMHeader(r *bufio.Reader, h netpbmHeader) (netpbmHeader, error) {
+	tupleType := ""
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return h, errors.New("unexpected end of pam header")
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "ENDHDR" {
+			break
+		}
+		if len(fields) < 2 {
+			return h, errors.New("invalid pam header line: " + strings.TrimSpace(line))
+		}
+		switch fields[0] {
+		case "WIDTH":
+			h.width, err = strconv.Atoi(fields[1])
+		case "HEIGHT":
+			h.height, err = strconv.Atoi(fields[1])
+		case "DEPTH":
+			h.depth, err = strconv.Atoi(fields[1])
+		case "MAXVAL":
+			h.maxVal, err = strconv.Atoi(fields[1])
+		case "TUPLTYPE":
+			tupleType = strings.Join(fields[1:], " ")
+		}
+		if err != nil {
+			return h, err
+		}
+	}
+	switch h.depth {
+	case 2, 4:
+		h.alpha = true
+	case 1, 3:
+	default:
+		return h, fmt.Errorf("unsupported pam depth %d", h.depth)
+	}
+	if strings.HasSuffix(tupleType, "_ALPHA") != h.alpha {
+		return h, fmt.Errorf("pam tuple type %q does not match depth %d", tupleType, h.depth)
+	}
+	return h, validateNetpbmHeader(h)
+}
+
+func validateNetpbmHeader(h netpbmHeader) error {
+	if h.width <= 0 || h.height <= 0 {
+		return errors.New("invalid netpbm image size")
+	}
+	if h.maxVal <= 0 || h.maxVal > int(^uint16(0)) {
+		return errors.New("invalid netpbm maxval")
+	}
+	if h.width > MaxNetpbmImageSize/h.height/netpbmBytesPerPixel(h) {
+		return errors.New("netpbm image too large")
+	}
+	return nil
+}
+
+// netpbmBytesPerPixel returns the number of bytes a pixel takes in the image newNetpbmImage allocates for the header.
+func netpbmBytesPerPixel(h netpbmHeader) int {
+	bytes := 4
+	if h.depth == 1 {
+		bytes = 1
+	}
+	if h.maxVal > int(utils.MaxUint8) {
+		bytes *= 2
+	}
+	return bytes
+}
+
+// skipNetpbmSpace skips the whitespaces and comments which may separate the tokens of a Netpbm header or plain raster.
+func skipNetpbmSpace(r *bufio.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == '#':
+			if _, err := r.ReadString('\n'); err != nil {
+				return err
+			}
+		case isNetpbmSpace(b):
+		default:
+			return r.UnreadByte()
+		}
+	}
+}
+
+func isNetpbmSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// readNetpbmInt reads a decimal value and consumes the single whitespace which terminates it.
+func readNetpbmInt(r *bufio.Reader) (int, error) {
+	if err := skipNetpbmSpace(r); err != nil {
+		return 0, err
+	}
+	value, digits := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF && digits > 0 {
+			return value, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if b < '0' || b > '9' {
+			if digits == 0 {
+				return 0, errors.New("invalid netpbm number")
+			}
+			if !isNetpbmSpace(b) {
+				return value, r.UnreadByte()
+			}
+			return value, nil
+		}
+		value = value*10 + int(b-'0')
+		if value > 1<<30 {
+			return 0, errors.New("netpbm number out of range")
+		}
+		digits++
+	}
+}
+
+func readPlainPBM(r *bufio.Reader, h netpbmHeader) (image.Image, error) {
+	img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+	for i := range img.Pix {
+		if err := skipNetpbmSpace(r); err != nil {
+			return nil, err
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch b {
+		case '0':
+			img.Pix[i] = 0xFF
+		case '1':
+			img.Pix[i] = 0x00
+		default:
+			return nil, errors.New("invalid pbm sample")
+		}
+	}
+	return img, nil
+}
+
+func readBinaryPBM(r *bufio.Reader, h netpbmHeader) (image.Image, error) {
+	img := image.NewGray(image.Rect(0, 0, h.width, h.height))
+	row := make([]byte, (h.width+7)/8)
+	for y := 0; y < h.height; y++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, err
+		}
+		for x := 0; x < h.width; x++ {
+			if row[x/8]&(0x80>>uint(x%8)) == 0 {
+				img.Pix[y*img.Stride+x] = 0xFF
+			}
+		}
+	}
+	return img, nil
+}
+
+// readNetpbmSamples reads the raster of PGM, PPM and PAM files. Files with a maxval above 255 are returned as 16 bit
+// images, so no precision is lost.
+func readNetpbmSamples(r *bufio.Reader, h netpbmHeader) (image.Image, error) {
+	plain := h.magic == '2' || h.magic == '3'
+	wide := h.maxVal > int(utils.MaxUint8)
+	buf := make([]byte, 2)
+	next := func() (uint32, error) {
+		var v int
+		if plain {
+			var err error
+			if v, err = readNetpbmInt(r); err != nil {
+				return 0, err
+			}
+		} else if wide {
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return 0, err
+			}
+			v = int(buf[0])<<8 | int(buf[1])
+		} else {
+			b, err := r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			v = int(b)
+		}
+		if v > h.maxVal {
+			return 0, errors.New("netpbm sample exceeds maxval")
+		}
+		return uint32(v), nil
+	}
+
+	maxOut := uint32(utils.MaxUint8)
+	if wide {
+		maxOut = uint32(utils.MaxUint16)
+	}
+	scale := func(v uint32) uint32 {
+		return (v*maxOut + uint32(h.maxVal)/2) / uint32(h.maxVal)
+	}
+
+	img := newNetpbmImage(h, wide)
+	samples := make([]uint32, h.depth)
+	for y := 0; y < h.height; y++ {
+		for x := 0; x < h.width; x++ {
+			for i := range samples {
+				v, err := next()
+				if err != nil {
+					return nil, err
+				}
+				samples[i] = scale(v)
+			}
+			img.Set(x, y, netpbmColor(samples, wide))
+		}
+	}
+	return img, nil
+}
+
+func newNetpbmImage(h netpbmHeader, wide bool) draw.Image {
+	rect := image.Rect(0, 0, h.width, h.height)
+	switch {
+	case h.depth == 1 && wide:
+		return image.NewGray16(rect)
+	case h.depth == 1:
+		return image.NewGray(rect)
+	case h.alpha && wide:
+		return image.NewNRGBA64(rect)
+	case h.alpha:
+		return image.NewNRGBA(rect)
+	case wide:
+		return image.NewRGBA64(rect)
+	}
+	return image.NewRGBA(rect)
+}
+
+func netpbmColor(s []uint32, wide bool) color.Color {
+	switch len(s) {
+	case 1:
+		if wide {
+			return color.Gray16{Y: uint16(s[0])}
+		}
+		return color.Gray{Y: uint8(s[0])}
+	case 2:
+		if wide {
+			return color.NRGBA64{R: uint16(s[0]), G: uint16(s[0]), B: uint16(s[0]), A: uint16(s[1])}
+		}
+		return color.NRGBA{R: uint8(s[0]), G: uint8(s[0]), B: uint8(s[0]), A: uint8(s[1])}
+	case 3:
+		if wide {
+			return color.RGBA64{R: uint16(s[0]), G: uint16(s[1]), B: uint16(s[2]), A: utils.MaxUint16}
+		}
+		return color.RGBA{R: uint8(s[0]), G: uint8(s[1]), B: uint8(s[2]), A: utils.MaxUint8}
+	}
+	if wide {
+		return color.NRGBA64{R: uint16(s[0]), G: uint16(s[1]), B: uint16(s[2]), A: uint16(s[3])}
+	}
+	return color.NRGBA{R: uint8(s[0]), G: uint8(s[1]), B: uint8(s[2]), A: uint8(s[3])}
+}
+
+func encodePBM(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	bounds := img.Bounds()
+	size := bounds.Size()
+	bw := bufio.NewWriter(w)
+	isBlack := func(x, y int) bool {
+		return color.GrayModel.Convert(img.At(x+bounds.Min.X, y+bounds.Min.Y)).(color.Gray).Y < 0x80
+	}
+	if opts.NetpbmPlain {
+		fmt.Fprintf(bw, "P1\n%d %d\n", size.X, size.Y)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				bit := byte('0')
+				if isBlack(x, y) {
+					bit = '1'
+				}
+				bw.WriteByte(bit)
+				if x%netpbmPlainLineLength == netpbmPlainLineLength-1 || x == size.X-1 {
+					bw.WriteByte('\n')
+				}
+			}
+		}
+		return bw.Flush()
+	}
+	fmt.Fprintf(bw, "P4\n%d %d\n", size.X, size.Y)
+	row := make([]byte, (size.X+7)/8)
+	for y := 0; y < size.Y; y++ {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := 0; x < size.X; x++ {
+			if isBlack(x, y) {
+				row[x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+		bw.Write(row)
+	}
+	return bw.Flush()
+}
+
+func encodePGM(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	magic := "P5"
+	if opts.NetpbmPlain {
+		magic = "P2"
+	}
+	return writeNetpbm(w, img, magic, 1, opts.NetpbmPlain, func(c color.Color, s []uint16) {
+		s[0] = color.Gray16Model.Convert(c).(color.Gray16).Y
+	})
+}
+
+func encodePPM(w io.Writer, img image.Image, opts *EncodeOptions) error {
+	magic := "P6"
+	if opts.NetpbmPlain {
+		magic = "P3"
+	}
+	return writeNetpbm(w, img, magic, 3, opts.NetpbmPlain, func(c color.Color, s []uint16) {
+		r, g, b, _ := c.RGBA()
+		s[0], s[1], s[2] = uint16(r), uint16(g), uint16(b)
+	})
+}
+
+// encodePAM writes a PAM file. Grayscale images are written as GRAYSCALE tuples, images which are not opaque as
+// RGB_ALPHA tuples and every other image as RGB tuples. PAM has no plain variant.
+func encodePAM(w io.Writer, img image.Image, _ *EncodeOptions) error {
+	switch img.ColorModel() {
+	case color.GrayModel, color.Gray16Model:
+		return writeNetpbm(w, img, "GRAYSCALE", 1, false, func(c color.Color, s []uint16) {
+			s[0] = color.Gray16Model.Convert(c).(color.Gray16).Y
+		})
+	}
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return writeNetpbm(w, img, "RGB", 3, false, func(c color.Color, s []uint16) {
+			r, g, b, _ := c.RGBA()
+			s[0], s[1], s[2] = uint16(r), uint16(g), uint16(b)
+		})
+	}
+	return writeNetpbm(w, img, "RGB_ALPHA", 4, false, func(c color.Color, s []uint16) {
+		n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+		s[0], s[1], s[2], s[3] = n.R, n.G, n.B, n.A
+	})
+}
+
+const netpbmPlainLineLength = 70
+
+// writeNetpbm writes the header and the raster of PGM, PPM and PAM files. For the PAM format the magic argument holds
+// the tuple type. The samples are written on 16 bits if the image has a 16 bit color model, otherwise on 8 bits.
+func writeNetpbm(w io.Writer, img image.Image, magic string, depth int, plain bool,
+	samples func(color.Color, []uint16)) error {
+	bounds := img.Bounds()
+	size := bounds.Size()
+	wide := false
+	switch img.ColorModel() {
+	case color.Gray16Model, color.RGBA64Model, color.NRGBA64Model:
+		wide = true
+	}
+	maxVal := int(utils.MaxUint8)
+	if wide {
+		maxVal = int(utils.MaxUint16)
+	}
+
+	bw := bufio.NewWriter(w)
+	if magic == "P2" || magic == "P3" || magic == "P5" || magic == "P6" {
+		fmt.Fprintf(bw, "%s\n%d %d\n%d\n", magic, size.X, size.Y, maxVal)
+	} else {
+		fmt.Fprintf(bw, "P7\nWIDTH %d\nHEIGHT %d\nDEPTH %d\nMAXVAL %d\nTUPLTYPE %s\nENDHDR\n",
+			size.X, size.Y, depth, maxVal, magic)
+	}
+
+	s := make([]uint16, depth)
+	for y := 0; y < size.Y; y++ {
+		lineLength := 0
+		for x := 0; x < size.X; x++ {
+			samples(img.At(x+bounds.Min.X, y+bounds.Min.Y), s)
+			for _, v := range s {
+				if !wide {
+					v >>= 8
+				}
+				switch {
+				case plain:
+					token := strconv.Itoa(int(v))
+					if lineLength > 0 && lineLength+len(token)+1 > netpbmPlainLineLength {
+						bw.WriteByte('\n')
+						lineLength = 0
+					} else if lineLength > 0 {
+						bw.WriteByte(' ')
+						lineLength++
+					}
+					bw.WriteString(token)
+					lineLength += len(token)
+				case wide:
+					bw.WriteByte(byte(v >> 8))
+					bw.WriteByte(byte(v))
+				default:
+					bw.WriteByte(byte(v))
+				}
+			}
+		}
+		if plain {
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
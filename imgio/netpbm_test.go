@@ -0,0 +1,205 @@
+package imgio
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests------------------------------------
+func Test_Decode_PlainPGM(t *testing.T) {
+	data := "P2\n# a comment\n3 2\n# another comment\n4\n0 1 2\n3 4 2\n"
+	actual, err := DecodeGray(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &image.Gray{
+		Rect:   image.Rect(0, 0, 3, 2),
+		Stride: 3,
+		Pix: []uint8{
+			0x00, 0x40, 0x80,
+			0xBF, 0xFF, 0x80,
+		},
+	}
+	utils.CompareGrayImages(t, expected, actual)
+}
+
+func Test_Decode_PlainPBM(t *testing.T) {
+	data := "P1\n4 2\n0110\n1 0 0 1\n"
+	actual, err := DecodeGray(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &image.Gray{
+		Rect:   image.Rect(0, 0, 4, 2),
+		Stride: 4,
+		Pix: []uint8{
+			0xFF, 0x00, 0x00, 0xFF,
+			0x00, 0xFF, 0xFF, 0x00,
+		},
+	}
+	utils.CompareGrayImages(t, expected, actual)
+}
+
+func Test_Decode_BinaryPGM16(t *testing.T) {
+	data := []byte("P5 2 1 65535\n\x12\x34\xAB\xCD")
+	actual, err := DecodeGray16(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Gray16At(0, 0).Y != 0x1234 || actual.Gray16At(1, 0).Y != 0xABCD {
+		t.Errorf("Expected samples 0x1234 0xABCD - actual: 0x%X 0x%X", actual.Gray16At(0, 0).Y,
+			actual.Gray16At(1, 0).Y)
+	}
+}
+
+func Test_Decode_InvalidNetpbm(t *testing.T) {
+	inputs := []string{
+		"P2\n2 2\n255\n1 2 3\n",
+		"P2\n2 1\n10\n1 11\n",
+		"P7\nWIDTH 1\nHEIGHT 1\nDEPTH 5\nMAXVAL 255\nENDHDR\n\x00\x00\x00\x00\x00",
+		"P5\n0 1\n255\n",
+		"P5 100000 100000 255\n",
+		"P6\n1073741824 1073741824\n65535\n",
+		"P7\nWIDTH 20000\nHEIGHT 20000\nDEPTH 4\nMAXVAL 255\nTUPLTYPE RGB_ALPHA\nENDHDR\n",
+	}
+	for _, input := range inputs {
+		if _, _, err := Decode(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for input %q", input)
+		}
+	}
+}
+
+func Test_DecodeGray_OversizedNetpbm(t *testing.T) {
+	if _, err := DecodeGray(strings.NewReader("P5 100000 100000 255\n")); err == nil {
+		t.Error("Expected an error for an image larger than MaxNetpbmImageSize")
+	}
+}
+
+func Test_Netpbm_RoundTrip_Gray(t *testing.T) {
+	img, err := ImreadGray("../res/engine.png")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	for _, opts := range []*EncodeOptions{
+		{Format: "pgm"},
+		{Format: "pgm", NetpbmPlain: true},
+		{Format: "pam"},
+	} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, opts); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := DecodeGray(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareGrayImages(t, img, actual)
+	}
+}
+
+func Test_Netpbm_RoundTrip_RGBA(t *testing.T) {
+	img, err := ImreadRGBA("../res/girl.jpg")
+	if err != nil {
+		t.Fatal("Could not read file!")
+	}
+	for _, opts := range []*EncodeOptions{
+		{Format: "ppm"},
+		{Format: "ppm", NetpbmPlain: true},
+		{Format: "pam"},
+	} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, opts); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := DecodeRGBA(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareRGBAImages(t, img, actual)
+	}
+}
+
+func Test_Netpbm_RoundTrip_PAMAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0x80})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 0xFF, G: 0x00, B: 0x7F, A: 0xFF})
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, &EncodeOptions{Format: "pam"}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, name, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "pam" {
+		t.Errorf("Expected format: pam - actual format: %s", name)
+	}
+	for x := 0; x < 2; x++ {
+		if decoded.At(x, 0) != img.At(x, 0) {
+			t.Errorf("Expected: %v - actual: %v at %d", img.At(x, 0), decoded.At(x, 0), x)
+		}
+	}
+}
+
+func Test_Netpbm_RoundTrip_16Bit(t *testing.T) {
+	gray16 := image.NewGray16(image.Rect(0, 0, 3, 2))
+	rgba64 := image.NewRGBA64(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		v := uint16(i*12345 + 7)
+		gray16.SetGray16(i%3, i/3, color.Gray16{Y: v})
+		rgba64.SetRGBA64(i%3, i/3, color.RGBA64{R: v, G: v + 1, B: v + 2, A: utils.MaxUint16})
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"gray.pgm", "gray.pam"} {
+		path := filepath.Join(dir, name)
+		if err := Imwrite(gray16, path); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := ImreadGray16(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(gray16.Pix, actual.Pix) {
+			t.Errorf("%s: 16 bit grayscale samples were not preserved", name)
+		}
+	}
+	for _, name := range []string{"color.ppm", "color.pam"} {
+		path := filepath.Join(dir, name)
+		if err := Imwrite(rgba64, path); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := ImreadRGBA64(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rgba64.Pix, actual.Pix) {
+			t.Errorf("%s: 16 bit color samples were not preserved", name)
+		}
+	}
+}
+
+func Test_Netpbm_RoundTrip_PBM(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 11, 3))
+	for i := range img.Pix {
+		if i%3 == 0 {
+			img.Pix[i] = utils.MaxUint8
+		}
+	}
+	for _, plain := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, img, &EncodeOptions{Format: "pbm", NetpbmPlain: plain}); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := DecodeGray(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareGrayImages(t, img, actual)
+	}
+}
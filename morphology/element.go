@@ -0,0 +1,151 @@
+package morphology
+
+import (
+	"errors"
+	"image"
+)
+
+// StructuringElement is a binary mask which defines the neighbourhood of a pixel for the morphological operations.
+// The values are indexed as Content[x][y], like the ones of convolution.Kernel. The Anchor is the position of the
+// element which is placed over the processed pixel.
+type StructuringElement struct {
+	Content [][]bool
+	Width   int
+	Height  int
+	Anchor  image.Point
+}
+
+// NewStructuringElement creates an empty StructuringElement with the given width and height, which can be filled
+// using Set. The anchor is placed in the center of the element.
+// Example of usage:
+//
+//	element, err := morphology.NewStructuringElement(3, 3)
+//	element.Set(0, 0, true)
+//	element.Set(1, 1, true)
+//	element.Set(2, 2, true)
+func NewStructuringElement(width int, height int) (*StructuringElement, error) {
+	if width < 1 || height < 1 {
+		return nil, errors.New("structuring element size must be positive")
+	}
+	content := make([][]bool, width)
+	for i := range content {
+		content[i] = make([]bool, height)
+	}
+	return &StructuringElement{
+		Content: content,
+		Width:   width,
+		Height:  height,
+		Anchor:  image.Point{X: width / 2, Y: height / 2},
+	}, nil
+}
+
+// NewRectElement creates a rectangular StructuringElement, every position of which is set. The morphological
+// operations use the van Herk/Gil-Werman algorithm for rectangular elements, the cost of which does not depend on the
+// size of the element.
+// Example of usage:
+//
+//	element, err := morphology.NewRectElement(5, 3)
+func NewRectElement(width int, height int) (*StructuringElement, error) {
+	e, err := NewStructuringElement(width, height)
+	if err != nil {
+		return nil, err
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			e.Set(x, y, true)
+		}
+	}
+	return e, nil
+}
+
+// NewCrossElement creates a cross shaped StructuringElement: the row and the column of the anchor are set.
+// Example of usage:
+//
+//	element, err := morphology.NewCrossElement(3, 3)
+func NewCrossElement(width int, height int) (*StructuringElement, error) {
+	e, err := NewStructuringElement(width, height)
+	if err != nil {
+		return nil, err
+	}
+	for x := 0; x < width; x++ {
+		e.Set(x, e.Anchor.Y, true)
+	}
+	for y := 0; y < height; y++ {
+		e.Set(e.Anchor.X, y, true)
+	}
+	return e, nil
+}
+
+// NewEllipseElement creates an elliptic StructuringElement, which is the ellipse inscribed into the width * height
+// rectangle.
+// Example of usage:
+//
+//	element, err := morphology.NewEllipseElement(7, 7)
+func NewEllipseElement(width int, height int) (*StructuringElement, error) {
+	e, err := NewStructuringElement(width, height)
+	if err != nil {
+		return nil, err
+	}
+	centerX, centerY := float64(width-1)/2, float64(height-1)/2
+	radiusX, radiusY := float64(width)/2, float64(height)/2
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			dx := (float64(x) - centerX) / radiusX
+			dy := (float64(y) - centerY) / radiusY
+			e.Set(x, y, dx*dx+dy*dy <= 1)
+		}
+	}
+	return e, nil
+}
+
+// At returns whether the position {x, y} of the element is set.
+func (e *StructuringElement) At(x, y int) bool {
+	return e.Content[x][y]
+}
+
+// Set sets the position {x, y} of the element.
+func (e *StructuringElement) Set(x int, y int, value bool) {
+	e.Content[x][y] = value
+}
+
+// Size returns the size of the element. The size is a type of image.Point containing the width and height of the
+// element.
+func (e *StructuringElement) Size() image.Point {
+	return image.Point{X: e.Width, Y: e.Height}
+}
+
+// -------------------------------------------------------------------------------------------------------
+func (e *StructuringElement) validate() error {
+	if e.Anchor.X < 0 || e.Anchor.Y < 0 || e.Anchor.X >= e.Width || e.Anchor.Y >= e.Height {
+		return errors.New("anchor is outside of the structuring element")
+	}
+	if len(e.offsets()) == 0 {
+		return errors.New("empty structuring element")
+	}
+	return nil
+}
+
+// isRect returns true if every position of the element is set.
+func (e *StructuringElement) isRect() bool {
+	for x := 0; x < e.Width; x++ {
+		for y := 0; y < e.Height; y++ {
+			if !e.At(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// offsets returns the set positions of the element relative to the anchor.
+func (e *StructuringElement) offsets() []image.Point {
+	var offsets []image.Point
+	for y := 0; y < e.Height; y++ {
+		for x := 0; x < e.Width; x++ {
+			if e.At(x, y) {
+				offsets = append(offsets, image.Point{X: x - e.Anchor.X, Y: y - e.Anchor.Y})
+			}
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,68 @@
+package morphology
+
+import (
+	"image"
+	"testing"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+func elementFromRows(rows []string) *StructuringElement {
+	e, _ := NewStructuringElement(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, c := range row {
+			e.Set(x, y, c == '#')
+		}
+	}
+	return e
+}
+
+func compareElements(t *testing.T, expected *StructuringElement, actual *StructuringElement) {
+	if expected.Size() != actual.Size() || expected.Anchor != actual.Anchor {
+		t.Fatalf("Expected size %v and anchor %v - actual size %v and anchor %v", expected.Size(), expected.Anchor,
+			actual.Size(), actual.Anchor)
+	}
+	for x := 0; x < expected.Width; x++ {
+		for y := 0; y < expected.Height; y++ {
+			if expected.At(x, y) != actual.At(x, y) {
+				t.Fatalf("Expected %v at (%d, %d) - actual %v", expected.At(x, y), x, y, actual.At(x, y))
+			}
+		}
+	}
+}
+
+func Test_NewRectElement(t *testing.T) {
+	e, err := NewRectElement(4, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	compareElements(t, elementFromRows([]string{"####", "####", "####"}), e)
+	if e.Anchor != (image.Point{X: 2, Y: 1}) || !e.isRect() {
+		t.Errorf("Expected rectangular element with anchor (2, 1) - actual anchor %v", e.Anchor)
+	}
+}
+
+func Test_NewCrossElement(t *testing.T) {
+	e, err := NewCrossElement(5, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	compareElements(t, elementFromRows([]string{"..#..", "#####", "..#.."}), e)
+}
+
+func Test_NewEllipseElement(t *testing.T) {
+	e, err := NewEllipseElement(5, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	compareElements(t, elementFromRows([]string{".###.", "#####", "#####", "#####", ".###."}), e)
+}
+
+func Test_NewStructuringElement_InvalidSize(t *testing.T) {
+	if _, err := NewStructuringElement(0, 3); err == nil {
+		t.Error("Expected error for zero width")
+	}
+	if _, err := NewRectElement(3, -1); err == nil {
+		t.Error("Expected error for negative height")
+	}
+}
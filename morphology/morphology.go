@@ -0,0 +1,312 @@
+// Package morphology implements the morphological operations (erosion, dilation and the operations built on them)
+// on grayscale and binary images.
+package morphology
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/utils"
+)
+
+// Operation is an enum type for the morphological operations
+type Operation int
+
+const (
+	// MorphErode - every pixel is replaced by the minimum of its neighbourhood, bright areas shrink
+	MorphErode Operation = iota
+	// MorphDilate - every pixel is replaced by the maximum of its neighbourhood, bright areas grow
+	MorphDilate
+	// MorphOpen - erosion followed by dilation, removes the bright details smaller than the element
+	MorphOpen
+	// MorphClose - dilation followed by erosion, fills the dark details smaller than the element
+	MorphClose
+	// MorphGradient - difference between the dilation and the erosion, the outlines of the objects
+	MorphGradient
+	// MorphTopHat - difference between the image and its opening, the bright details smaller than the element
+	MorphTopHat
+	// MorphBlackHat - difference between the closing and the image, the dark details smaller than the element
+	MorphBlackHat
+)
+
+// MorphologyGray applies a morphological operation to a grayscale image. The erosions and dilations are repeated
+// iterations times, for the compound operations (open, close, etc.) every erosion and dilation is repeated. The pixels
+// outside of the image are read according to the border: BorderReplicate keeps the objects touching the edge of the
+// image unchanged, while BorderConstant treats the outside as black. Binary images (such as the output of
+// threshold.Threshold) stay binary, so the same functions implement the binary morphology.
+// The dilation uses the reflected element, so opening and closing are idempotent for asymmetric elements too.
+// Example of usage:
+//
+//	element, _ := morphology.NewEllipseElement(5, 5)
+//	res, err := morphology.MorphologyGray(img, morphology.MorphOpen, element, 1, padding.BorderReplicate)
+func MorphologyGray(img *image.Gray, operation Operation, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGrayCtx(context.Background(), img, operation, element, iterations, border, nil)
+}
+
+// MorphologyGrayCtx is MorphologyGray which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func MorphologyGrayCtx(ctx context.Context, img *image.Gray, operation Operation, element *StructuringElement,
+	iterations int, border padding.Border, opts *utils.Options) (*image.Gray, error) {
+	if iterations < 1 {
+		return nil, errors.New("iterations must be bigger then 0")
+	}
+	if err := element.validate(); err != nil {
+		return nil, err
+	}
+	erode := func(img *image.Gray) (*image.Gray, error) {
+		return repeat(ctx, img, element, iterations, border, false, opts)
+	}
+	dilate := func(img *image.Gray) (*image.Gray, error) {
+		return repeat(ctx, img, element, iterations, border, true, opts)
+	}
+	switch operation {
+	case MorphErode:
+		return erode(img)
+	case MorphDilate:
+		return dilate(img)
+	case MorphOpen:
+		eroded, err := erode(img)
+		if err != nil {
+			return nil, err
+		}
+		return dilate(eroded)
+	case MorphClose:
+		dilated, err := dilate(img)
+		if err != nil {
+			return nil, err
+		}
+		return erode(dilated)
+	case MorphGradient:
+		dilated, err := dilate(img)
+		if err != nil {
+			return nil, err
+		}
+		eroded, err := erode(img)
+		if err != nil {
+			return nil, err
+		}
+		return subtract(opts.ExecutorOrDefault(), dilated, eroded), nil
+	case MorphTopHat:
+		opened, err := MorphologyGrayCtx(ctx, img, MorphOpen, element, iterations, border, opts)
+		if err != nil {
+			return nil, err
+		}
+		return subtract(opts.ExecutorOrDefault(), img, opened), nil
+	case MorphBlackHat:
+		closed, err := MorphologyGrayCtx(ctx, img, MorphClose, element, iterations, border, opts)
+		if err != nil {
+			return nil, err
+		}
+		return subtract(opts.ExecutorOrDefault(), closed, img), nil
+	}
+	return nil, errors.New("invalid morphological operation")
+}
+
+// ErodeGray applies erosion to a grayscale image. See MorphologyGray.
+// Example of usage:
+//
+//	res, err := morphology.ErodeGray(img, element, 1, padding.BorderReplicate)
+func ErodeGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphErode, element, iterations, border)
+}
+
+// DilateGray applies dilation to a grayscale image. See MorphologyGray.
+// Example of usage:
+//
+//	res, err := morphology.DilateGray(img, element, 1, padding.BorderReplicate)
+func DilateGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphDilate, element, iterations, border)
+}
+
+// OpenGray applies opening (erosion followed by dilation) to a grayscale image. See MorphologyGray.
+func OpenGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphOpen, element, iterations, border)
+}
+
+// CloseGray applies closing (dilation followed by erosion) to a grayscale image. See MorphologyGray.
+func CloseGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphClose, element, iterations, border)
+}
+
+// GradientGray computes the morphological gradient (dilation minus erosion) of a grayscale image. See MorphologyGray.
+func GradientGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphGradient, element, iterations, border)
+}
+
+// TopHatGray computes the top-hat (image minus opening) of a grayscale image. See MorphologyGray.
+func TopHatGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphTopHat, element, iterations, border)
+}
+
+// BlackHatGray computes the black-hat (closing minus image) of a grayscale image. See MorphologyGray.
+func BlackHatGray(img *image.Gray, element *StructuringElement, iterations int,
+	border padding.Border) (*image.Gray, error) {
+	return MorphologyGray(img, MorphBlackHat, element, iterations, border)
+}
+
+// -------------------------------------------------------------------------------------------------------
+// repeat applies erosion (or dilation if dilate is true) iterations times.
+func repeat(ctx context.Context, img *image.Gray, element *StructuringElement, iterations int, border padding.Border,
+	dilate bool, opts *utils.Options) (*image.Gray, error) {
+	res := img
+	for i := 0; i < iterations; i++ {
+		var err error
+		if element.isRect() {
+			res, err = morphRect(ctx, res, element, border, dilate, opts)
+		} else {
+			res, err = morphGeneric(ctx, res, element, border, dilate, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// selector returns the function which selects the result of two values: min for erosion, max for dilation.
+func selector(dilate bool) func(a, b uint8) uint8 {
+	if dilate {
+		return func(a, b uint8) uint8 {
+			if a > b {
+				return a
+			}
+			return b
+		}
+	}
+	return func(a, b uint8) uint8 {
+		if a < b {
+			return a
+		}
+		return b
+	}
+}
+
+// morphGeneric computes the minimum (or maximum) over the set positions of the element for every pixel.
+func morphGeneric(ctx context.Context, img *image.Gray, element *StructuringElement, border padding.Border,
+	dilate bool, opts *utils.Options) (*image.Gray, error) {
+	sampler, err := padding.NewGraySamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	offsets := element.offsets()
+	if dilate {
+		// the dilation uses the element reflected through the anchor
+		for i := range offsets {
+			offsets[i] = offsets[i].Mul(-1)
+		}
+	}
+	pick := selector(dilate)
+	size := img.Bounds().Size()
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		value := sampler.GrayAt(x+offsets[0].X, y+offsets[0].Y).Y
+		for _, o := range offsets[1:] {
+			value = pick(value, sampler.GrayAt(x+o.X, y+o.Y).Y)
+		}
+		res.Pix[y*res.Stride+x] = value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// morphRect computes the minimum (or maximum) over a rectangular element as a horizontal and a vertical pass, both
+// using the van Herk/Gil-Werman algorithm.
+func morphRect(ctx context.Context, img *image.Gray, element *StructuringElement, border padding.Border,
+	dilate bool, opts *utils.Options) (*image.Gray, error) {
+	anchor := element.Anchor
+	if dilate {
+		anchor = image.Point{X: element.Width - 1 - anchor.X, Y: element.Height - 1 - anchor.Y}
+	}
+	pick := selector(dilate)
+	size := img.Bounds().Size()
+
+	sampler, err := padding.NewGraySamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	horizontal := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), image.Point{X: 1, Y: size.Y}, func(_, y int) {
+		line := make([]uint8, size.X+element.Width-1)
+		for i := range line {
+			line[i] = sampler.GrayAt(i-anchor.X, y).Y
+		}
+		vanHerkGilWerman(line, element.Width, pick, horizontal.Pix[y*horizontal.Stride:y*horizontal.Stride+size.X])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sampler, err = padding.NewGraySamplerWithFill(horizontal, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), image.Point{X: 1, Y: size.X}, func(_, x int) {
+		line := make([]uint8, size.Y+element.Height-1)
+		for i := range line {
+			line[i] = sampler.GrayAt(x, i-anchor.Y).Y
+		}
+		column := make([]uint8, size.Y)
+		vanHerkGilWerman(line, element.Height, pick, column)
+		for y, value := range column {
+			res.Pix[y*res.Stride+x] = value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// vanHerkGilWerman computes out[i] = pick(line[i], ..., line[i+window-1]) using 3 comparisons per value regardless of
+// the window size. The line is divided into blocks of window values, for which the running results from the start
+// (prefix) and from the end (suffix) of the block are computed. Every window covers the end of one block and the start
+// of the next one, so its result is pick(suffix[i], prefix[i+window-1]).
+func vanHerkGilWerman(line []uint8, window int, pick func(a, b uint8) uint8, out []uint8) {
+	n := len(line)
+	prefix := make([]uint8, n)
+	suffix := make([]uint8, n)
+	for i := 0; i < n; i++ {
+		if i%window == 0 {
+			prefix[i] = line[i]
+		} else {
+			prefix[i] = pick(prefix[i-1], line[i])
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		if i == n-1 || (i+1)%window == 0 {
+			suffix[i] = line[i]
+		} else {
+			suffix[i] = pick(suffix[i+1], line[i])
+		}
+	}
+	for i := range out {
+		out[i] = pick(suffix[i], prefix[i+window-1])
+	}
+}
+
+// subtract returns a - b, limited to 0. The rows are processed by e.
+func subtract(e utils.Executor, a *image.Gray, b *image.Gray) *image.Gray {
+	size := a.Bounds().Size()
+	offsetA, offsetB := a.Bounds().Min, b.Bounds().Min
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	utils.IteratePixelsWith(e, size, func(x, y int) {
+		va := a.GrayAt(x+offsetA.X, y+offsetA.Y).Y
+		vb := b.GrayAt(x+offsetB.X, y+offsetB.Y).Y
+		if va > vb {
+			res.Pix[y*res.Stride+x] = va - vb
+		}
+	})
+	return res
+}
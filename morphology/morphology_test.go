@@ -0,0 +1,360 @@
+package morphology
+
+import (
+	"context"
+	"image"
+	"math/rand"
+	"testing"
+
+	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/padding"
+	"github.com/ernyoke/imger/threshold"
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+// binaryFromRows creates a binary image in which '#' is white and everything else is black.
+func binaryFromRows(rows []string) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				img.Pix[y*img.Stride+x] = 255
+			}
+		}
+	}
+	return img
+}
+
+func randomGray(width, height int, seed int64) *image.Gray {
+	r := rand.New(rand.NewSource(seed))
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.Intn(256))
+	}
+	return img
+}
+
+var borders = []padding.Border{padding.BorderConstant, padding.BorderReplicate, padding.BorderReflect,
+	padding.BorderWrap}
+
+func Test_ErodeGray_Binary(t *testing.T) {
+	img := binaryFromRows([]string{
+		"#.......",
+		"..####..",
+		"..####..",
+		"..####..",
+		"..####..",
+		"........",
+	})
+	square, _ := NewRectElement(3, 3)
+	actual, err := ErodeGray(img, square, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := binaryFromRows([]string{
+		"........",
+		"........",
+		"...##...",
+		"...##...",
+		"........",
+		"........",
+	})
+	utils.CompareGrayImages(t, expected, actual)
+}
+
+func Test_DilateGray_Cross(t *testing.T) {
+	img := binaryFromRows([]string{
+		".....",
+		".....",
+		"..#..",
+		".....",
+		".....",
+	})
+	cross, _ := NewCrossElement(3, 3)
+	actual, err := DilateGray(img, cross, 2, padding.BorderConstant)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := binaryFromRows([]string{
+		"..#..",
+		".###.",
+		"#####",
+		".###.",
+		"..#..",
+	})
+	utils.CompareGrayImages(t, expected, actual)
+}
+
+func Test_MorphologyGray_RectMatchesGeneric(t *testing.T) {
+	img := randomGray(23, 17, 1)
+	rect, _ := NewRectElement(5, 4)
+	rect.Anchor = image.Point{X: 1, Y: 3}
+	ctx := context.Background()
+	for _, opts := range []*utils.Options{nil, {Fill: image.White.C}} {
+		for _, border := range borders {
+			for _, dilate := range []bool{false, true} {
+				expected, err := morphGeneric(ctx, img, rect, border, dilate, opts)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				actual, err := morphRect(ctx, img, rect, border, dilate, opts)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				utils.CompareGrayImages(t, expected, actual)
+			}
+		}
+	}
+}
+
+func Test_MorphologyGray_Iterations(t *testing.T) {
+	img := randomGray(20, 20, 2)
+	small, _ := NewRectElement(3, 3)
+	large, _ := NewRectElement(7, 7)
+	for _, operation := range []Operation{MorphErode, MorphDilate} {
+		expected, _ := MorphologyGray(img, operation, large, 1, padding.BorderReplicate)
+		actual, err := MorphologyGray(img, operation, small, 3, padding.BorderReplicate)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		utils.CompareGrayImages(t, expected, actual)
+	}
+}
+
+func Test_OpenGray_CloseGray(t *testing.T) {
+	square, _ := NewRectElement(3, 3)
+	img := binaryFromRows([]string{
+		"#.........",
+		"..#####...",
+		"..#####...",
+		"..#####..#",
+		"..#####...",
+		"..........",
+	})
+	opened, err := OpenGray(img, square, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the isolated pixels are removed, the block is kept
+	utils.CompareGrayImages(t, binaryFromRows([]string{
+		"..........",
+		"..#####...",
+		"..#####...",
+		"..#####...",
+		"..#####...",
+		"..........",
+	}), opened)
+
+	img = binaryFromRows([]string{
+		"..........",
+		"..#####...",
+		"..##.##...",
+		"..#.###...",
+		"..#####...",
+		"..........",
+	})
+	// with BorderReplicate the dilated block would reach the edges and stay there
+	closed, err := CloseGray(img, square, 1, padding.BorderConstant)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the holes are filled
+	utils.CompareGrayImages(t, binaryFromRows([]string{
+		"..........",
+		"..#####...",
+		"..#####...",
+		"..#####...",
+		"..#####...",
+		"..........",
+	}), closed)
+
+	for _, operation := range []Operation{MorphOpen, MorphClose} {
+		once, _ := MorphologyGray(img, operation, square, 1, padding.BorderReplicate)
+		twice, _ := MorphologyGray(once, operation, square, 1, padding.BorderReplicate)
+		utils.CompareGrayImages(t, once, twice)
+	}
+}
+
+func Test_OpenGray_AsymmetricElementIsAntiExtensive(t *testing.T) {
+	img := randomGray(16, 16, 3)
+	element := elementFromRows([]string{"##.", ".##", "..#"})
+	element.Anchor = image.Point{X: 0, Y: 0}
+	opened, err := OpenGray(img, element, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	closed, err := CloseGray(img, element, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the pixels near the edge depend on the border, the element reaches 2 pixels from its anchor
+	for y := 2; y < 14; y++ {
+		for x := 2; x < 14; x++ {
+			i := y*img.Stride + x
+			if opened.Pix[i] > img.Pix[i] || closed.Pix[i] < img.Pix[i] {
+				t.Fatalf("Expected opening <= image <= closing at (%d, %d) - actual: %d, %d, %d", x, y,
+					opened.Pix[i], img.Pix[i], closed.Pix[i])
+			}
+		}
+	}
+}
+
+func Test_GradientGray_TopHat_BlackHat(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 9, 9))
+	for i := range img.Pix {
+		img.Pix[i] = 100
+	}
+	img.Pix[2*img.Stride+2] = 180
+	img.Pix[6*img.Stride+6] = 30
+	square, _ := NewRectElement(3, 3)
+
+	gradient, err := GradientGray(img, square, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gradient.GrayAt(4, 4).Y != 0 || gradient.GrayAt(2, 3).Y != 80 || gradient.GrayAt(5, 6).Y != 70 {
+		t.Errorf("Unexpected gradient values: %d, %d, %d", gradient.GrayAt(4, 4).Y, gradient.GrayAt(2, 3).Y,
+			gradient.GrayAt(5, 6).Y)
+	}
+
+	topHat, err := TopHatGray(img, square, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	blackHat, err := BlackHatGray(img, square, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedTopHat := image.NewGray(img.Bounds())
+	expectedTopHat.Pix[2*img.Stride+2] = 80
+	utils.CompareGrayImages(t, expectedTopHat, topHat)
+	expectedBlackHat := image.NewGray(img.Bounds())
+	expectedBlackHat.Pix[6*img.Stride+6] = 70
+	utils.CompareGrayImages(t, expectedBlackHat, blackHat)
+}
+
+func Test_MorphologyGray_Cropped(t *testing.T) {
+	img := randomGray(20, 20, 4)
+	cropped := img.SubImage(image.Rect(3, 5, 15, 18)).(*image.Gray)
+	copied := image.NewGray(image.Rect(0, 0, 12, 13))
+	for y := 0; y < 13; y++ {
+		copy(copied.Pix[y*copied.Stride:], cropped.Pix[y*cropped.Stride:y*cropped.Stride+12])
+	}
+	ellipse, _ := NewEllipseElement(5, 5)
+	square, _ := NewRectElement(3, 3)
+	for _, element := range []*StructuringElement{ellipse, square} {
+		for _, operation := range []Operation{MorphGradient, MorphTopHat, MorphBlackHat} {
+			expected, _ := MorphologyGray(copied, operation, element, 1, padding.BorderReflect)
+			actual, err := MorphologyGray(cropped, operation, element, 1, padding.BorderReflect)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			utils.CompareGrayImages(t, expected, actual)
+		}
+	}
+}
+
+func Test_MorphologyGray_Errors(t *testing.T) {
+	img := randomGray(4, 4, 5)
+	square, _ := NewRectElement(3, 3)
+	if _, err := ErodeGray(img, square, 0, padding.BorderReplicate); err == nil {
+		t.Error("Expected error for 0 iterations")
+	}
+	if _, err := MorphologyGray(img, Operation(42), square, 1, padding.BorderReplicate); err == nil {
+		t.Error("Expected error for invalid operation")
+	}
+	empty, _ := NewStructuringElement(3, 3)
+	if _, err := DilateGray(img, empty, 1, padding.BorderReplicate); err == nil {
+		t.Error("Expected error for empty element")
+	}
+	outside, _ := NewRectElement(3, 3)
+	outside.Anchor = image.Point{X: 3, Y: 0}
+	if _, err := DilateGray(img, outside, 1, padding.BorderReplicate); err == nil {
+		t.Error("Expected error for anchor outside of the element")
+	}
+	if _, err := ErodeGray(img, square, 1, padding.Border(42)); err == nil {
+		t.Error("Expected error for unknown border")
+	}
+}
+
+func Test_MorphologyGrayCtx_Cancelled(t *testing.T) {
+	img := randomGray(16, 16, 6)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	square, _ := NewRectElement(3, 3)
+	cross, _ := NewCrossElement(3, 3)
+	for _, element := range []*StructuringElement{square, cross} {
+		_, err := MorphologyGrayCtx(ctx, img, MorphOpen, element, 1, padding.BorderReplicate, nil)
+		if err != context.Canceled {
+			t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+		}
+	}
+}
+
+// countingExecutor processes the rows sequentially and counts the calls of IterateRows.
+type countingExecutor struct {
+	calls int
+}
+
+func (c *countingExecutor) IterateRows(height int, f func(startY, endY int)) {
+	c.calls++
+	utils.Sequential.IterateRows(height, f)
+}
+
+func Test_Subtract_UsesExecutor(t *testing.T) {
+	a := &image.Gray{Rect: image.Rect(0, 0, 3, 1), Stride: 3, Pix: []uint8{10, 20, 30}}
+	b := &image.Gray{Rect: image.Rect(0, 0, 3, 1), Stride: 3, Pix: []uint8{20, 20, 5}}
+	executor := &countingExecutor{}
+	res := subtract(executor, a, b)
+	utils.CompareGrayImages(t, &image.Gray{Rect: a.Rect, Stride: 3, Pix: []uint8{0, 0, 25}}, res)
+	if executor.calls != 1 {
+		t.Errorf("Expected the rows to be processed by the executor - actual calls: %d", executor.calls)
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+func setupTestCaseBinary(t *testing.T) *image.Gray {
+	path := "../res/building.jpg"
+	img, err := imgio.ImreadGray(path)
+	if err != nil {
+		t.Fatalf("Could not read image from path: %s", path)
+	}
+	binary, err := threshold.OtsuThreshold(img, threshold.ThreshBinary)
+	if err != nil {
+		t.Fatalf("Could not threshold image: %v", err)
+	}
+	return binary
+}
+
+func tearDownTestCase(t *testing.T, img image.Image, path string) {
+	err := imgio.Imwrite(img, path)
+	if err != nil {
+		t.Errorf("Could not write image to path: %s", path)
+	}
+}
+
+func Test_Acceptance_OpenBinary(t *testing.T) {
+	binary := setupTestCaseBinary(t)
+	ellipse, _ := NewEllipseElement(5, 5)
+	res, err := OpenGray(binary, ellipse, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Should not reach this point!")
+	}
+	tearDownTestCase(t, res, "../res/morphology/openBinary.png")
+}
+
+func Test_Acceptance_GradientGray(t *testing.T) {
+	path := "../res/engine.png"
+	img, err := imgio.ImreadGray(path)
+	if err != nil {
+		t.Fatalf("Could not read image from path: %s", path)
+	}
+	square, _ := NewRectElement(3, 3)
+	res, err := GradientGray(img, square, 1, padding.BorderReplicate)
+	if err != nil {
+		t.Fatalf("Should not reach this point!")
+	}
+	tearDownTestCase(t, res, "../res/morphology/gradientGray.png")
+}
@@ -12,14 +12,21 @@ import (
 type Border int
 
 const (
-	// BorderConstant - xxxabcdefghxxx - where x is a black ( color.Gray{0} ) pixel
+	// BorderConstant - xxxabcdefghxxx - where x is a black ( color.Gray{0} ) pixel, or the fill color given to the
+	// WithFill functions or set in the utils.Options of the Ctx operations
 	BorderConstant Border = iota
 	// BorderReplicate - aaaabcdefghhhh - replicates the nearest pixel
 	BorderReplicate
-	// BorderReflect - cbabcdefgfed - reflects the nearest pixel group
+	// BorderReflect - cbabcdefgfed - reflects the image without repeating the edge pixel, like the default border of
+	// OpenCV (BORDER_REFLECT_101). Paddings larger than the image are reflected back and forth.
 	BorderReflect
+	// BorderWrap - fghabcdefghabc - repeats the image as if it was tiled
+	BorderWrap
 )
 
+// BorderReflect101 is an alias of BorderReflect under the name of the equivalent border of OpenCV (BORDER_REFLECT_101).
+const BorderReflect101 = BorderReflect
+
 // Paddings struct holds the padding sizes for each padding
 type Paddings struct {
 	// PaddingLeft is the size of the left padding
@@ -36,7 +43,7 @@ func topPaddingReplicate(img image.Image, p Paddings, setPixel func(int, int, co
 	originalSize := img.Bounds().Size()
 	offset := img.Bounds().Min
 	for x := p.PaddingLeft; x < originalSize.X+p.PaddingLeft; x++ {
-		firstPixel := img.At(x-p.PaddingLeft+offset.X, p.PaddingTop+offset.Y)
+		firstPixel := img.At(x-p.PaddingLeft+offset.X, offset.Y)
 		for y := 0; y < p.PaddingTop; y++ {
 			setPixel(x, y, firstPixel)
 		}
@@ -74,50 +81,8 @@ func rightPaddingReplicate(img image.Image, padded image.Image, p Paddings, setP
 	}
 }
 
-func topPaddingReflect(img image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	offset := img.Bounds().Min
-	for x := p.PaddingLeft; x < originalSize.X+p.PaddingLeft; x++ {
-		for y := 0; y < p.PaddingTop; y++ {
-			pixel := img.At(x-p.PaddingLeft+offset.X, p.PaddingTop-y+offset.Y)
-			setPixel(x, y, pixel)
-		}
-	}
-}
-
-func bottomPaddingReflect(img image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	offset := img.Bounds().Min
-	for x := p.PaddingLeft; x < originalSize.X+p.PaddingLeft; x++ {
-		for y := p.PaddingTop + originalSize.Y; y < originalSize.Y+p.PaddingTop+p.PaddingBottom; y++ {
-			pixel := img.At(x-p.PaddingLeft+offset.X, originalSize.Y-(y-p.PaddingTop-originalSize.Y)-2+offset.Y)
-			setPixel(x, y, pixel)
-		}
-	}
-}
-
-func leftPaddingReflect(img image.Image, padded image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	for y := 0; y < originalSize.Y+p.PaddingBottom+p.PaddingTop; y++ {
-		for x := 0; x < p.PaddingLeft; x++ {
-			pixel := padded.At(2*p.PaddingLeft-x, y)
-			setPixel(x, y, pixel)
-		}
-	}
-}
-
-func rightPaddingReflect(img image.Image, padded image.Image, p Paddings, setPixel func(int, int, color.Color)) {
-	originalSize := img.Bounds().Size()
-	for y := 0; y < originalSize.Y+p.PaddingBottom+p.PaddingTop; y++ {
-		for x := originalSize.X + p.PaddingLeft; x < originalSize.X+p.PaddingLeft+p.PaddingRight; x++ {
-			pixel := padded.At(originalSize.X+p.PaddingLeft-(x-originalSize.X-p.PaddingLeft)-2, y)
-			setPixel(x, y, pixel)
-		}
-	}
-}
-
 // PaddingGray appends padding to a given grayscale image. The size of the padding is calculated from the kernel size
-// and the anchor point. Supported border types are: BorderConstant, BorderReplicate, BorderReflect.
+// and the anchor point. Supported border types are: BorderConstant, BorderReplicate, BorderReflect, BorderWrap.
 // Example of usage:
 //
 //	res, err := padding.PaddingGray(img, {5, 5}, {1, 1}, BorderReflect)
@@ -125,41 +90,35 @@ func rightPaddingReflect(img image.Image, padded image.Image, p Paddings, setPix
 // Note: this will add a 1px padding for the top and left borders of the image and a 3px padding fot the bottom and
 // right borders of the image.
 func PaddingGray(img *image.Gray, kernelSize image.Point, anchor image.Point, border Border) (*image.Gray, error) {
+	return PaddingGrayWithFill(img, kernelSize, anchor, border, color.Transparent)
+}
+
+// PaddingGrayWithFill is PaddingGray where the padding of BorderConstant is filled with the given color instead of
+// black. The color is ignored by the other border types.
+// Example of usage:
+//
+//	res, err := padding.PaddingGrayWithFill(img, {5, 5}, {2, 2}, padding.BorderConstant, color.White)
+func PaddingGrayWithFill(img *image.Gray, kernelSize image.Point, anchor image.Point, border Border,
+	fill color.Color) (*image.Gray, error) {
 	p, err := calculatePaddings(kernelSize, anchor)
 	if err != nil {
 		return nil, err
 	}
 	padded := blankPaddingGray(img, p)
+	setPixel := func(x int, y int, pixel color.Color) {
+		padded.Set(x, y, pixel)
+	}
 
 	switch border {
 	case BorderConstant:
-		// do nothing
+		constantPadding(img, p, fill, setPixel)
 	case BorderReplicate:
-		topPaddingReplicate(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		bottomPaddingReplicate(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		leftPaddingReplicate(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		rightPaddingReplicate(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-	case BorderReflect:
-		topPaddingReflect(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		bottomPaddingReflect(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		leftPaddingReflect(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		rightPaddingReflect(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
+		topPaddingReplicate(img, p, setPixel)
+		bottomPaddingReplicate(img, p, setPixel)
+		leftPaddingReplicate(img, padded, p, setPixel)
+		rightPaddingReplicate(img, padded, p, setPixel)
+	case BorderReflect, BorderWrap:
+		mappedPadding(img, p, border, setPixel)
 	default:
 		return nil, errors.New("unknown border type")
 	}
@@ -167,7 +126,7 @@ func PaddingGray(img *image.Gray, kernelSize image.Point, anchor image.Point, bo
 }
 
 // PaddingRGBA appends padding to a given RGBA image. The size of the padding is calculated from the kernel size
-// and the anchor point. Supported border types are: BorderConstant, BorderReplicate, BorderReflect.
+// and the anchor point. Supported border types are: BorderConstant, BorderReplicate, BorderReflect, BorderWrap.
 // Example of usage:
 //
 //	res, err := padding.PaddingRGBA(img, {5, 5}, {1, 1}, BorderReflect)
@@ -175,41 +134,35 @@ func PaddingGray(img *image.Gray, kernelSize image.Point, anchor image.Point, bo
 // Note: this will add a 1px padding for the top and left borders of the image and a 3px padding fot the bottom and
 // right borders of the image.
 func PaddingRGBA(img *image.RGBA, kernelSize image.Point, anchor image.Point, border Border) (*image.RGBA, error) {
+	return PaddingRGBAWithFill(img, kernelSize, anchor, border, color.Transparent)
+}
+
+// PaddingRGBAWithFill is PaddingRGBA where the padding of BorderConstant is filled with the given color instead of
+// transparent black. The color is ignored by the other border types.
+// Example of usage:
+//
+//	res, err := padding.PaddingRGBAWithFill(img, {5, 5}, {2, 2}, padding.BorderConstant, color.White)
+func PaddingRGBAWithFill(img *image.RGBA, kernelSize image.Point, anchor image.Point, border Border,
+	fill color.Color) (*image.RGBA, error) {
 	p, err := calculatePaddings(kernelSize, anchor)
 	if err != nil {
 		return nil, err
 	}
 	padded := blankPaddingRGBA(img, p)
+	setPixel := func(x int, y int, pixel color.Color) {
+		padded.Set(x, y, pixel)
+	}
 
 	switch border {
 	case BorderConstant:
-		// do nothing
+		constantPadding(img, p, fill, setPixel)
 	case BorderReplicate:
-		topPaddingReplicate(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		bottomPaddingReplicate(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		leftPaddingReplicate(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		rightPaddingReplicate(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-	case BorderReflect:
-		topPaddingReflect(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		bottomPaddingReflect(img, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		leftPaddingReflect(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
-		rightPaddingReflect(img, padded, p, func(x int, y int, pixel color.Color) {
-			padded.Set(x, y, pixel)
-		})
+		topPaddingReplicate(img, p, setPixel)
+		bottomPaddingReplicate(img, p, setPixel)
+		leftPaddingReplicate(img, padded, p, setPixel)
+		rightPaddingReplicate(img, padded, p, setPixel)
+	case BorderReflect, BorderWrap:
+		mappedPadding(img, p, border, setPixel)
 	default:
 		return nil, errors.New("unknown border type")
 	}
@@ -245,6 +198,73 @@ func blankPaddingRGBA(img *image.RGBA, padding Paddings) *image.RGBA {
 }
 
 // -------------------------------------------------------------------------------------------------------
+// constantPadding fills the padding with the given color. The new image is already black, so nothing is done for
+// transparent black.
+func constantPadding(img image.Image, p Paddings, fill color.Color, setPixel func(int, int, color.Color)) {
+	if color.RGBAModel.Convert(fill) == (color.RGBA{}) {
+		return
+	}
+	forEachPaddingPixel(img, p, func(x int, y int) {
+		setPixel(x, y, fill)
+	})
+}
+
+// forEachPaddingPixel calls f for every position of the padded image which is outside of the original image.
+func forEachPaddingPixel(img image.Image, p Paddings, f func(x int, y int)) {
+	originalSize := img.Bounds().Size()
+	width := originalSize.X + p.PaddingLeft + p.PaddingRight
+	height := originalSize.Y + p.PaddingTop + p.PaddingBottom
+	for y := 0; y < height; y++ {
+		inside := y >= p.PaddingTop && y < p.PaddingTop+originalSize.Y
+		for x := 0; x < width; x++ {
+			if inside && x >= p.PaddingLeft && x < p.PaddingLeft+originalSize.X {
+				continue
+			}
+			f(x, y)
+		}
+	}
+}
+
+// mappedPadding fills the padding by mapping every padding position to a position of the original image.
+func mappedPadding(img image.Image, p Paddings, border Border, setPixel func(int, int, color.Color)) {
+	originalSize := img.Bounds().Size()
+	if originalSize.X == 0 || originalSize.Y == 0 {
+		return
+	}
+	offset := img.Bounds().Min
+	forEachPaddingPixel(img, p, func(x int, y int) {
+		sx := borderIndex(x-p.PaddingLeft, originalSize.X, border)
+		sy := borderIndex(y-p.PaddingTop, originalSize.Y, border)
+		setPixel(x, y, img.At(sx+offset.X, sy+offset.Y))
+	})
+}
+
+// borderIndex maps the index i, which may be outside of [0, n), to an index inside of [0, n) using the given border.
+func borderIndex(i int, n int, border Border) int {
+	switch border {
+	case BorderWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+	case BorderReflect:
+		if n == 1 {
+			return 0
+		}
+		period := 2*n - 2
+		i %= period
+		if i < 0 {
+			i += period
+		}
+		if i >= n {
+			i = period - i
+		}
+	default:
+		i = utils.ClampInt(i, 0, n-1)
+	}
+	return i
+}
+
 func calculatePaddings(kernelSize image.Point, anchor image.Point) (Paddings, error) {
 	var p Paddings
 	if kernelSize.X < 0 || kernelSize.Y < 0 {
@@ -2,6 +2,7 @@ package padding
 
 import (
 	"image"
+	"image/color"
 	"testing"
 
 	"github.com/ernyoke/imger/imgio"
@@ -183,6 +184,35 @@ func Test_GrayPaddingBorderReplicate_1_3pxPadding(t *testing.T) {
 	utils.CompareGrayImages(t, &expected, actual)
 }
 
+func Test_GrayPaddingBorderReplicate_DistinctRows(t *testing.T) {
+	gray := image.Gray{
+		Rect:   image.Rect(0, 0, 3, 3),
+		Stride: 3,
+		Pix: []uint8{
+			0x11, 0x22, 0x33,
+			0x44, 0x55, 0x66,
+			0x77, 0x88, 0x99,
+		},
+	}
+	expected := image.Gray{
+		Rect:   image.Rect(0, 0, 5, 7),
+		Stride: 5,
+		Pix: []uint8{
+			0x11, 0x11, 0x22, 0x33, 0x33,
+			0x11, 0x11, 0x22, 0x33, 0x33,
+			0x11, 0x11, 0x22, 0x33, 0x33,
+			0x44, 0x44, 0x55, 0x66, 0x66,
+			0x77, 0x77, 0x88, 0x99, 0x99,
+			0x77, 0x77, 0x88, 0x99, 0x99,
+			0x77, 0x77, 0x88, 0x99, 0x99,
+		},
+	}
+	paddingSize := image.Point{X: 3, Y: 5}
+	anchor := image.Point{X: 1, Y: 2}
+	actual, _ := PaddingGray(&gray, paddingSize, anchor, BorderReplicate)
+	utils.CompareGrayImages(t, &expected, actual)
+}
+
 func Test_GrayPaddingBorderReflect_1_3pxPadding(t *testing.T) {
 	gray := image.Gray{
 		Rect:   image.Rect(0, 0, 5, 3),
@@ -244,6 +274,124 @@ func Test_GrayPaddingBorderReflect_2pxPadding(t *testing.T) {
 	utils.CompareGrayImages(t, &expected, actual)
 }
 
+func Test_GrayPaddingBorderWrap_2pxPadding(t *testing.T) {
+	gray := image.Gray{
+		Rect:   image.Rect(0, 0, 3, 2),
+		Stride: 3,
+		Pix: []uint8{
+			0xAA, 0xBB, 0xCC,
+			0x11, 0x22, 0x33,
+		},
+	}
+	expected := image.Gray{
+		Rect:   image.Rect(0, 0, 7, 6),
+		Stride: 7,
+		Pix: []uint8{
+			0x22, 0x33, 0x11, 0x22, 0x33, 0x11, 0x22,
+			0xBB, 0xCC, 0xAA, 0xBB, 0xCC, 0xAA, 0xBB,
+			0x22, 0x33, 0x11, 0x22, 0x33, 0x11, 0x22,
+			0xBB, 0xCC, 0xAA, 0xBB, 0xCC, 0xAA, 0xBB,
+			0x22, 0x33, 0x11, 0x22, 0x33, 0x11, 0x22,
+			0xBB, 0xCC, 0xAA, 0xBB, 0xCC, 0xAA, 0xBB,
+		},
+	}
+	paddingSize := image.Point{X: 5, Y: 5}
+	anchor := image.Point{X: 2, Y: 3}
+	actual, err := PaddingGray(&gray, paddingSize, anchor, BorderWrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImages(t, &expected, actual)
+}
+
+func Test_GrayPaddingBorderReflect_LargerThanImage(t *testing.T) {
+	gray := image.Gray{
+		Rect:   image.Rect(0, 0, 3, 1),
+		Stride: 3,
+		Pix:    []uint8{0xAA, 0xBB, 0xCC},
+	}
+	expected := image.Gray{
+		Rect:   image.Rect(0, 0, 11, 1),
+		Stride: 11,
+		Pix:    []uint8{0xAA, 0xBB, 0xCC, 0xBB, 0xAA, 0xBB, 0xCC, 0xBB, 0xAA, 0xBB, 0xCC},
+	}
+	actual, err := PaddingGray(&gray, image.Point{X: 9, Y: 1}, image.Point{X: 4, Y: 0}, BorderReflect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImages(t, &expected, actual)
+}
+
+func Test_GrayPaddingWithFill(t *testing.T) {
+	gray := image.Gray{
+		Rect:   image.Rect(0, 0, 2, 1),
+		Stride: 2,
+		Pix:    []uint8{0xAA, 0xBB},
+	}
+	expected := image.Gray{
+		Rect:   image.Rect(0, 0, 4, 3),
+		Stride: 4,
+		Pix: []uint8{
+			0x80, 0x80, 0x80, 0x80,
+			0x80, 0xAA, 0xBB, 0x80,
+			0x80, 0x80, 0x80, 0x80,
+		},
+	}
+	fill := color.Gray{Y: 0x80}
+	actual, err := PaddingGrayWithFill(&gray, image.Point{X: 3, Y: 3}, image.Point{X: 1, Y: 1}, BorderConstant, fill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.CompareGrayImages(t, &expected, actual)
+}
+
+func Test_RGBAPaddingWithFill(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	inner := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			rgba.SetRGBA(x, y, inner)
+		}
+	}
+	fill := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	actual, err := PaddingRGBAWithFill(rgba, image.Point{X: 3, Y: 2}, image.Point{X: 2, Y: 0}, BorderConstant, fill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := actual.Bounds().Size()
+	if size.X != 4 || size.Y != 3 {
+		t.Fatalf("Expected size: 4x3 - actual size: %dx%d", size.X, size.Y)
+	}
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			expected := fill
+			if x >= 2 && y < 2 {
+				expected = inner
+			}
+			if actual.RGBAAt(x, y) != expected {
+				t.Errorf("Expected: %v - actual: %v at %d, %d", expected, actual.RGBAAt(x, y), x, y)
+			}
+		}
+	}
+}
+
+func Test_PaddingWithFill_IgnoredByOtherBorders(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	cropped := gray.SubImage(image.Rect(100, 100, 120, 110)).(*image.Gray)
+	for _, border := range []Border{BorderReplicate, BorderReflect, BorderWrap} {
+		expected, err := PaddingGray(cropped, image.Point{X: 5, Y: 5}, image.Point{X: 2, Y: 2}, border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := PaddingGrayWithFill(cropped, image.Point{X: 5, Y: 5}, image.Point{X: 2, Y: 2}, border,
+			color.White)
+		if err != nil {
+			t.Fatal(err)
+		}
+		utils.CompareGrayImages(t, expected, actual)
+	}
+}
+
 // ---------------------------------------------------------------------------------
 
 // -----------------------------Acceptance tests------------------------------------
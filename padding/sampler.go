@@ -0,0 +1,138 @@
+package padding
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// GraySampler reads the pixels of a grayscale image at any position. The positions outside of the image are mapped
+// according to a Border, so the result is the same as reading from a padded copy of the image, but no copy is
+// allocated. The positions are relative to the top-left corner of the image (img.Bounds().Min).
+type GraySampler struct {
+	img      *image.Gray
+	border   Border
+	constant color.Gray
+	size     image.Point
+}
+
+// NewGraySampler creates a GraySampler for the given image and border. Returns an error for an unknown border type.
+// Example of usage:
+//
+//	s, err := padding.NewGraySampler(img, padding.BorderReflect)
+//	pixel := s.GrayAt(-2, 5)
+func NewGraySampler(img *image.Gray, border Border) (*GraySampler, error) {
+	return NewGraySamplerWithFill(img, border, color.Transparent)
+}
+
+// NewGraySamplerWithFill is NewGraySampler where the positions outside of the image are read as the given color instead
+// of black if the border is BorderConstant.
+// Example of usage:
+//
+//	s, err := padding.NewGraySamplerWithFill(img, padding.BorderConstant, color.White)
+func NewGraySamplerWithFill(img *image.Gray, border Border, fill color.Color) (*GraySampler, error) {
+	if err := validateBorder(border); err != nil {
+		return nil, err
+	}
+	return &GraySampler{
+		img:      img,
+		border:   border,
+		constant: color.GrayModel.Convert(fill).(color.Gray),
+		size:     img.Bounds().Size(),
+	}, nil
+}
+
+// Size returns the size of the sampled image.
+func (s *GraySampler) Size() image.Point {
+	return s.size
+}
+
+// GrayAt returns the pixel at {x, y}, which may be outside of the image.
+func (s *GraySampler) GrayAt(x int, y int) color.Gray {
+	if x >= 0 && y >= 0 && x < s.size.X && y < s.size.Y {
+		return color.Gray{Y: s.img.Pix[y*s.img.Stride+x]}
+	}
+	x, y, ok := mapPosition(x, y, s.size, s.border)
+	if !ok {
+		return s.constant
+	}
+	return color.Gray{Y: s.img.Pix[y*s.img.Stride+x]}
+}
+
+// RGBASampler reads the pixels of an RGBA image at any position. See GraySampler.
+type RGBASampler struct {
+	img      *image.RGBA
+	border   Border
+	constant color.RGBA
+	size     image.Point
+}
+
+// NewRGBASampler creates an RGBASampler for the given image and border. Returns an error for an unknown border type.
+// Example of usage:
+//
+//	s, err := padding.NewRGBASampler(img, padding.BorderWrap)
+//	pixel := s.RGBAAt(x+width, y)
+func NewRGBASampler(img *image.RGBA, border Border) (*RGBASampler, error) {
+	return NewRGBASamplerWithFill(img, border, color.Transparent)
+}
+
+// NewRGBASamplerWithFill is NewRGBASampler where the positions outside of the image are read as the given color
+// instead of transparent black if the border is BorderConstant.
+// Example of usage:
+//
+//	s, err := padding.NewRGBASamplerWithFill(img, padding.BorderConstant, color.White)
+func NewRGBASamplerWithFill(img *image.RGBA, border Border, fill color.Color) (*RGBASampler, error) {
+	if err := validateBorder(border); err != nil {
+		return nil, err
+	}
+	return &RGBASampler{
+		img:      img,
+		border:   border,
+		constant: color.RGBAModel.Convert(fill).(color.RGBA),
+		size:     img.Bounds().Size(),
+	}, nil
+}
+
+// Size returns the size of the sampled image.
+func (s *RGBASampler) Size() image.Point {
+	return s.size
+}
+
+// RGBAAt returns the pixel at {x, y}, which may be outside of the image.
+func (s *RGBASampler) RGBAAt(x int, y int) color.RGBA {
+	if x < 0 || y < 0 || x >= s.size.X || y >= s.size.Y {
+		var ok bool
+		x, y, ok = mapPosition(x, y, s.size, s.border)
+		if !ok {
+			return s.constant
+		}
+	}
+	i := y*s.img.Stride + 4*x
+	pix := s.img.Pix[i : i+4 : i+4]
+	return color.RGBA{R: pix[0], G: pix[1], B: pix[2], A: pix[3]}
+}
+
+// NewPaddings calculates the paddings which are needed around an image for applying a kernel of the given size with
+// the given anchor point. Returns an error for a negative size or for an anchor outside of the kernel.
+func NewPaddings(kernelSize image.Point, anchor image.Point) (Paddings, error) {
+	return calculatePaddings(kernelSize, anchor)
+}
+
+// -------------------------------------------------------------------------------------------------------
+// validateBorder returns an error for an unknown border type.
+func validateBorder(border Border) error {
+	switch border {
+	case BorderConstant, BorderReplicate, BorderReflect, BorderWrap:
+		return nil
+	}
+	return errors.New("unknown border type")
+}
+
+// mapPosition maps a position outside of an image with the given size to a position of the image. Returns false if the
+// position is filled with the constant color of the border.
+func mapPosition(x int, y int, size image.Point, border Border) (int, int, bool) {
+	if border == BorderConstant || size.X == 0 || size.Y == 0 {
+		return 0, 0, false
+	}
+	return borderIndex(x, size.X, border), borderIndex(y, size.Y, border), true
+}
@@ -0,0 +1,79 @@
+package padding
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+var samplerBorders = []Border{BorderConstant, BorderReplicate, BorderReflect, BorderWrap}
+
+var samplerFills = []color.Color{color.Transparent, color.RGBA{R: 10, G: 20, B: 30, A: 255}}
+
+func Test_GraySampler_MatchesPaddingGray(t *testing.T) {
+	gray := setupTestCaseGray(t)
+	cropped := gray.SubImage(image.Rect(100, 100, 140, 130)).(*image.Gray)
+	kernelSize := image.Point{X: 11, Y: 7}
+	anchor := image.Point{X: 3, Y: 5}
+	for _, border := range samplerBorders {
+		for _, fill := range samplerFills {
+			padded, err := PaddingGrayWithFill(cropped, kernelSize, anchor, border, fill)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sampler, err := NewGraySamplerWithFill(cropped, border, fill)
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := padded.Bounds().Size()
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					expected := padded.GrayAt(x, y)
+					actual := sampler.GrayAt(x-anchor.X, y-anchor.Y)
+					if expected != actual {
+						t.Errorf("Border %d, fill %v: expected: %v - actual: %v at %d, %d", border, fill, expected,
+							actual, x, y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_RGBASampler_MatchesPaddingRGBA(t *testing.T) {
+	rgba := setupTestCaseRGBA(t)
+	cropped := rgba.SubImage(image.Rect(200, 150, 230, 190)).(*image.RGBA)
+	kernelSize := image.Point{X: 9, Y: 9}
+	anchor := image.Point{X: 4, Y: 2}
+	for _, border := range samplerBorders {
+		for _, fill := range samplerFills {
+			padded, err := PaddingRGBAWithFill(cropped, kernelSize, anchor, border, fill)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sampler, err := NewRGBASamplerWithFill(cropped, border, fill)
+			if err != nil {
+				t.Fatal(err)
+			}
+			size := padded.Bounds().Size()
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					expected := padded.RGBAAt(x, y)
+					actual := sampler.RGBAAt(x-anchor.X, y-anchor.Y)
+					if expected != actual {
+						t.Errorf("Border %d, fill %v: expected: %v - actual: %v at %d, %d", border, fill, expected,
+							actual, x, y)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_NewGraySampler_UnknownBorder(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	if _, err := NewGraySampler(gray, Border(42)); err == nil {
+		t.Error("Expected an error for an unknown border")
+	}
+}
@@ -1,14 +1,20 @@
 package resize
 
 import (
+	"context"
 	"errors"
 	"image"
 	"image/color"
 	"math"
 
+	"github.com/ernyoke/imger/padding"
 	"github.com/ernyoke/imger/utils"
 )
 
+// resizeBorder decides how the positions outside of the original image are read, for example by the filter windows
+// next to the edges of the image.
+const resizeBorder = padding.BorderReplicate
+
 // Interpolation method types
 type Interpolation int
 
@@ -23,26 +29,33 @@ const (
 	InterLanczos
 )
 
-func resizeNearestGray(img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
+func resizeNearestGray(ctx context.Context, img *image.Gray, fx float64, fy float64,
+	opts *utils.Options) (*image.Gray, error) {
+	sampler, err := padding.NewGraySampler(img, resizeBorder)
+	if err != nil {
+		return nil, err
+	}
 	oldSize := img.Bounds().Size()
-	oldOffset := img.Bounds().Min
 	newSize := image.Point{X: int(float64(oldSize.X) * fx), Y: int(float64(oldSize.Y) * fy)}
 	res := image.NewGray(image.Rect(0, 0, newSize.X, newSize.Y))
-	utils.IteratePixels(newSize, func(x, y int) {
-		oldX := int(math.Round(float64(x)/fx)) + oldOffset.X
-		oldY := int(math.Round(float64(y)/fy)) + oldOffset.Y
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), newSize, func(x, y int) {
+		oldX := int(math.Round(float64(x) / fx))
+		oldY := int(math.Round(float64(y) / fy))
 
-		res.SetGray(x, y, img.GrayAt(oldX, oldY))
+		res.SetGray(x, y, sampler.GrayAt(oldX, oldY))
 	})
+	if err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
-func resizeLinearGray(img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
-	res, err := resizeHorizontalGray(img, fx, NewLinear())
+func resizeLinearGray(ctx context.Context, img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
+	res, err := resizeHorizontalGray(ctx, img, fx, NewLinear())
 	if err != nil {
 		return nil, err
 	}
-	res, err = resizeVerticalGray(res, fy, NewLinear())
+	res, err = resizeVerticalGray(ctx, res, fy, NewLinear())
 	if err != nil {
 		return nil, err
 	}
@@ -50,12 +63,12 @@ func resizeLinearGray(img *image.Gray, fx float64, fy float64) (*image.Gray, err
 	return res, nil
 }
 
-func resizeCatmullRomGray(img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
-	res, err := resizeHorizontalGray(img, fx, NewCatmullRom())
+func resizeCatmullRomGray(ctx context.Context, img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
+	res, err := resizeHorizontalGray(ctx, img, fx, NewCatmullRom())
 	if err != nil {
 		return nil, err
 	}
-	res, err = resizeVerticalGray(res, fy, NewCatmullRom())
+	res, err = resizeVerticalGray(ctx, res, fy, NewCatmullRom())
 	if err != nil {
 		return nil, err
 	}
@@ -63,12 +76,12 @@ func resizeCatmullRomGray(img *image.Gray, fx float64, fy float64) (*image.Gray,
 	return res, nil
 }
 
-func resizeLanczosGray(img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
-	res, err := resizeHorizontalGray(img, fx, NewLanczos())
+func resizeLanczosGray(ctx context.Context, img *image.Gray, fx float64, fy float64) (*image.Gray, error) {
+	res, err := resizeHorizontalGray(ctx, img, fx, NewLanczos())
 	if err != nil {
 		return nil, err
 	}
-	res, err = resizeVerticalGray(res, fy, NewLanczos())
+	res, err = resizeVerticalGray(ctx, res, fy, NewLanczos())
 	if err != nil {
 		return nil, err
 	}
@@ -76,24 +89,30 @@ func resizeLanczosGray(img *image.Gray, fx float64, fy float64) (*image.Gray, er
 	return res, nil
 }
 
-func resizeHorizontalGray(img *image.Gray, fx float64, filter Filter) (*image.Gray, error) {
+func resizeHorizontalGray(ctx context.Context, img *image.Gray, fx float64, filter Filter) (*image.Gray, error) {
+	sampler, err := padding.NewGraySampler(img, resizeBorder)
+	if err != nil {
+		return nil, err
+	}
 	originalSize := img.Bounds().Size()
-	offset := img.Bounds().Min
 	newWidth := int(float64(originalSize.X) * fx)
 	res := image.NewGray(image.Rect(0, 0, newWidth, originalSize.Y))
 	dfx := 1 / fx
 
 	radius := math.Ceil(fx * filter.GetS())
 	for y := 0; y < originalSize.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		for x := 0; x < newWidth; x++ {
 			ix := (float64(x)+0.5)*dfx - 0.5
-			start := utils.ClampInt(int(ix-radius+0.5), 0, originalSize.X)
-			end := utils.ClampInt(int(ix+radius), 0, originalSize.X)
+			start := int(math.Floor(ix - radius + 0.5))
+			end := int(math.Floor(ix + radius))
 			var fPix float64
 			var sum float64
 			for i := start; i < end; i++ {
 				filterValue := filter.Interpolate(float64(i)-ix) / fx
-				pix := img.GrayAt(i+offset.X, y+offset.Y)
+				pix := sampler.GrayAt(i, y)
 				fPix += float64(pix.Y) * filterValue
 				sum += filterValue
 			}
@@ -103,24 +122,30 @@ func resizeHorizontalGray(img *image.Gray, fx float64, filter Filter) (*image.Gr
 	return res, nil
 }
 
-func resizeVerticalGray(img *image.Gray, fy float64, filter Filter) (*image.Gray, error) {
+func resizeVerticalGray(ctx context.Context, img *image.Gray, fy float64, filter Filter) (*image.Gray, error) {
+	sampler, err := padding.NewGraySampler(img, resizeBorder)
+	if err != nil {
+		return nil, err
+	}
 	originalSize := img.Bounds().Size()
-	offset := img.Bounds().Min
 	newHeight := int(float64(originalSize.Y) * fy)
 	res := image.NewGray(image.Rect(0, 0, originalSize.X, newHeight))
 	dfy := 1 / fy
 
 	radius := math.Ceil(fy * filter.GetS())
 	for y := 0; y < newHeight; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		iy := (float64(y)+0.5)*dfy - 0.5
-		start := utils.ClampInt(int(iy-radius+0.5), 0, originalSize.Y)
-		end := utils.ClampInt(int(iy+radius), 0, originalSize.Y)
+		start := int(math.Floor(iy - radius + 0.5))
+		end := int(math.Floor(iy + radius))
 		for x := 0; x < originalSize.X; x++ {
 			var sum float64
 			var fPix float64
 			for i := start; i < end; i++ {
 				filterValue := filter.Interpolate(float64(i)-iy) / fy
-				pix := img.GrayAt(x+offset.X, i+offset.Y)
+				pix := sampler.GrayAt(x, i)
 				fPix += float64(pix.Y) * filterValue
 				sum += filterValue
 			}
@@ -130,26 +155,33 @@ func resizeVerticalGray(img *image.Gray, fy float64, filter Filter) (*image.Gray
 	return res, nil
 }
 
-func resizeNearestRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
+func resizeNearestRGBA(ctx context.Context, img *image.RGBA, fx float64, fy float64,
+	opts *utils.Options) (*image.RGBA, error) {
+	sampler, err := padding.NewRGBASampler(img, resizeBorder)
+	if err != nil {
+		return nil, err
+	}
 	oldSize := img.Bounds().Size()
-	oldOffset := img.Bounds().Min
 	newSize := image.Point{X: int(float64(oldSize.X) * fx), Y: int(float64(oldSize.Y) * fy)}
 	res := image.NewRGBA(image.Rect(0, 0, newSize.X, newSize.Y))
-	utils.IteratePixels(newSize, func(x, y int) {
-		oldX := int(math.Round(float64(x)/fx)) + oldOffset.X
-		oldY := int(math.Round(float64(y)/fy)) + oldOffset.Y
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), newSize, func(x, y int) {
+		oldX := int(math.Round(float64(x) / fx))
+		oldY := int(math.Round(float64(y) / fy))
 
-		res.SetRGBA(x, y, img.RGBAAt(oldX, oldY))
+		res.SetRGBA(x, y, sampler.RGBAAt(oldX, oldY))
 	})
+	if err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
-func resizeLinearRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
-	res, err := resizeHorizontalRGBA(img, fx, NewLinear())
+func resizeLinearRGBA(ctx context.Context, img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
+	res, err := resizeHorizontalRGBA(ctx, img, fx, NewLinear())
 	if err != nil {
 		return nil, err
 	}
-	res, err = resizeVerticalRGBA(res, fy, NewLinear())
+	res, err = resizeVerticalRGBA(ctx, res, fy, NewLinear())
 	if err != nil {
 		return nil, err
 	}
@@ -157,12 +189,12 @@ func resizeLinearRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA, err
 	return res, nil
 }
 
-func resizeCatmullRomRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
-	res, err := resizeHorizontalRGBA(img, fx, NewCatmullRom())
+func resizeCatmullRomRGBA(ctx context.Context, img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
+	res, err := resizeHorizontalRGBA(ctx, img, fx, NewCatmullRom())
 	if err != nil {
 		return nil, err
 	}
-	res, err = resizeVerticalRGBA(res, fy, NewCatmullRom())
+	res, err = resizeVerticalRGBA(ctx, res, fy, NewCatmullRom())
 	if err != nil {
 		return nil, err
 	}
@@ -170,12 +202,12 @@ func resizeCatmullRomRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA,
 	return res, nil
 }
 
-func resizeLanczosRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
-	res, err := resizeHorizontalRGBA(img, fx, NewLanczos())
+func resizeLanczosRGBA(ctx context.Context, img *image.RGBA, fx float64, fy float64) (*image.RGBA, error) {
+	res, err := resizeHorizontalRGBA(ctx, img, fx, NewLanczos())
 	if err != nil {
 		return nil, err
 	}
-	res, err = resizeVerticalRGBA(res, fy, NewLanczos())
+	res, err = resizeVerticalRGBA(ctx, res, fy, NewLanczos())
 	if err != nil {
 		return nil, err
 	}
@@ -183,9 +215,12 @@ func resizeLanczosRGBA(img *image.RGBA, fx float64, fy float64) (*image.RGBA, er
 	return res, nil
 }
 
-func resizeHorizontalRGBA(img *image.RGBA, fx float64, filter Filter) (*image.RGBA, error) {
+func resizeHorizontalRGBA(ctx context.Context, img *image.RGBA, fx float64, filter Filter) (*image.RGBA, error) {
+	sampler, err := padding.NewRGBASampler(img, resizeBorder)
+	if err != nil {
+		return nil, err
+	}
 	originalSize := img.Bounds().Size()
-	offset := img.Bounds().Min
 
 	newWidth := int(float64(originalSize.X) * fx)
 	res := image.NewRGBA(image.Rect(0, 0, newWidth, originalSize.Y))
@@ -193,10 +228,13 @@ func resizeHorizontalRGBA(img *image.RGBA, fx float64, filter Filter) (*image.RG
 
 	radius := math.Ceil(fx * filter.GetS())
 	for y := 0; y < originalSize.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		for x := 0; x < newWidth; x++ {
 			ix := (float64(x)+0.5)*dfx - 0.5
-			start := utils.ClampInt(int(ix-radius+0.5), 0, originalSize.X)
-			end := utils.ClampInt(int(ix+radius), 0, originalSize.X)
+			start := int(math.Floor(ix - radius + 0.5))
+			end := int(math.Floor(ix + radius))
 			var fPixR float64
 			var fPixG float64
 			var fPixB float64
@@ -204,7 +242,7 @@ func resizeHorizontalRGBA(img *image.RGBA, fx float64, filter Filter) (*image.RG
 			var sum float64
 			for i := start; i < end; i++ {
 				filterValue := filter.Interpolate(float64(i)-ix) / fx
-				pix := img.RGBAAt(i+offset.X, y+offset.Y)
+				pix := sampler.RGBAAt(i, y)
 				fPixR += float64(pix.R) * filterValue
 				fPixG += float64(pix.G) * filterValue
 				fPixB += float64(pix.B) * filterValue
@@ -220,9 +258,12 @@ func resizeHorizontalRGBA(img *image.RGBA, fx float64, filter Filter) (*image.RG
 	return res, nil
 }
 
-func resizeVerticalRGBA(img *image.RGBA, fy float64, filter Filter) (*image.RGBA, error) {
+func resizeVerticalRGBA(ctx context.Context, img *image.RGBA, fy float64, filter Filter) (*image.RGBA, error) {
+	sampler, err := padding.NewRGBASampler(img, resizeBorder)
+	if err != nil {
+		return nil, err
+	}
 	originalSize := img.Bounds().Size()
-	offset := img.Bounds().Min
 
 	newHeight := int(float64(originalSize.Y) * fy)
 	res := image.NewRGBA(image.Rect(0, 0, originalSize.X, newHeight))
@@ -230,9 +271,12 @@ func resizeVerticalRGBA(img *image.RGBA, fy float64, filter Filter) (*image.RGBA
 
 	radius := math.Ceil(fy * filter.GetS())
 	for y := 0; y < newHeight; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		iy := (float64(y)+0.5)*dfy - 0.5
-		start := utils.ClampInt(int(iy-radius+0.5), 0, originalSize.Y)
-		end := utils.ClampInt(int(iy+radius), 0, originalSize.Y)
+		start := int(math.Floor(iy - radius + 0.5))
+		end := int(math.Floor(iy + radius))
 		for x := 0; x < originalSize.X; x++ {
 			var fPixR float64
 			var fPixG float64
@@ -241,7 +285,7 @@ func resizeVerticalRGBA(img *image.RGBA, fy float64, filter Filter) (*image.RGBA
 			var sum float64
 			for i := start; i < end; i++ {
 				filterValue := filter.Interpolate(float64(i)-iy) / fy
-				pix := img.RGBAAt(x+offset.X, i+offset.Y)
+				pix := sampler.RGBAAt(x, i)
 				fPixR += float64(pix.R) * filterValue
 				fPixG += float64(pix.G) * filterValue
 				fPixB += float64(pix.B) * filterValue
@@ -265,18 +309,25 @@ func resizeVerticalRGBA(img *image.RGBA, fy float64, filter Filter) (*image.RGBA
 //
 //	res, err := resize.ResizeGray(img, 2.5, 3.5, resize.InterLinear)
 func ResizeGray(img *image.Gray, fx float64, fy float64, interpolation Interpolation) (*image.Gray, error) {
+	return ResizeGrayCtx(context.Background(), img, fx, fy, interpolation, nil)
+}
+
+// ResizeGrayCtx is ResizeGray which can be aborted using ctx. The context is checked between the rows of the result,
+// ctx.Err() is returned if it was cancelled.
+func ResizeGrayCtx(ctx context.Context, img *image.Gray, fx float64, fy float64,
+	interpolation Interpolation, opts *utils.Options) (*image.Gray, error) {
 	if fx < 0 || fy < 0 {
 		return nil, errors.New("scale value should be greater then 0")
 	}
 	switch interpolation {
 	case InterNearest:
-		return resizeNearestGray(img, fx, fy)
+		return resizeNearestGray(ctx, img, fx, fy, opts)
 	case InterLinear:
-		return resizeLinearGray(img, fx, fy)
+		return resizeLinearGray(ctx, img, fx, fy)
 	case InterCatmullRom:
-		return resizeCatmullRomGray(img, fx, fy)
+		return resizeCatmullRomGray(ctx, img, fx, fy)
 	case InterLanczos:
-		return resizeLanczosGray(img, fx, fy)
+		return resizeLanczosGray(ctx, img, fx, fy)
 	}
 	return nil, errors.New("invalid interpolation method")
 }
@@ -289,18 +340,25 @@ func ResizeGray(img *image.Gray, fx float64, fy float64, interpolation Interpola
 //
 //	res, err := resize.ResizeRGBA(img, 2.5, 3.5, resize.InterLinear)
 func ResizeRGBA(img *image.RGBA, fx float64, fy float64, interpolation Interpolation) (*image.RGBA, error) {
+	return ResizeRGBACtx(context.Background(), img, fx, fy, interpolation, nil)
+}
+
+// ResizeRGBACtx is ResizeRGBA which can be aborted using ctx. The context is checked between the rows of the result,
+// ctx.Err() is returned if it was cancelled.
+func ResizeRGBACtx(ctx context.Context, img *image.RGBA, fx float64, fy float64,
+	interpolation Interpolation, opts *utils.Options) (*image.RGBA, error) {
 	if fx < 0 || fy < 0 {
 		return nil, errors.New("scale value should be greater then 0")
 	}
 	switch interpolation {
 	case InterNearest:
-		return resizeNearestRGBA(img, fx, fy)
+		return resizeNearestRGBA(ctx, img, fx, fy, opts)
 	case InterLinear:
-		return resizeLinearRGBA(img, fx, fy)
+		return resizeLinearRGBA(ctx, img, fx, fy)
 	case InterCatmullRom:
-		return resizeCatmullRomRGBA(img, fx, fy)
+		return resizeCatmullRomRGBA(ctx, img, fx, fy)
 	case InterLanczos:
-		return resizeLanczosRGBA(img, fx, fy)
+		return resizeLanczosRGBA(ctx, img, fx, fy)
 	}
 	return nil, errors.New("invalid interpolation method")
 }
@@ -1,12 +1,47 @@
 package resize
 
 import (
+	"context"
 	"image"
 	"testing"
 
 	"github.com/ernyoke/imger/imgio"
 )
 
+// ---------------------------------Unit tests------------------------------------
+func Test_ResizeCtx_Cancelled(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 16, 16))
+	rgba := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, interpolation := range []Interpolation{InterNearest, InterLinear, InterCatmullRom, InterLanczos} {
+		if _, err := ResizeGrayCtx(ctx, gray, 2, 2, interpolation, nil); err != context.Canceled {
+			t.Errorf("ResizeGrayCtx(%d): expected error %v - actual error %v", interpolation, context.Canceled, err)
+		}
+		if _, err := ResizeRGBACtx(ctx, rgba, 2, 2, interpolation, nil); err != context.Canceled {
+			t.Errorf("ResizeRGBACtx(%d): expected error %v - actual error %v", interpolation, context.Canceled, err)
+		}
+	}
+}
+
+func Test_ResizeGray_KeepsEdges(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 3, 3))
+	for i := range gray.Pix {
+		gray.Pix[i] = 100
+	}
+	for _, interpolation := range []Interpolation{InterNearest, InterLinear, InterCatmullRom, InterLanczos} {
+		res, err := ResizeGray(gray, 2, 2, interpolation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, pix := range res.Pix {
+			if pix != 100 {
+				t.Errorf("ResizeGray(%d): expected: 100 - actual: %d at %d", interpolation, pix, i)
+			}
+		}
+	}
+}
+
 // -----------------------------Acceptance tests------------------------------------
 func setupTestCaseGray(t *testing.T) *image.Gray {
 	path := "../res/girl.jpg"
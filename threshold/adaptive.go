@@ -0,0 +1,259 @@
+package threshold
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// AdaptiveMethod is an enum type for the ways of computing the local threshold of AdaptiveThreshold
+type AdaptiveMethod int
+
+const (
+	// AdaptiveMean - the threshold is the mean of the blockSize * blockSize neighbourhood minus C
+	AdaptiveMean AdaptiveMethod = iota
+	// AdaptiveGaussian - the threshold is the Gaussian weighted mean of the blockSize * blockSize neighbourhood minus C
+	AdaptiveGaussian
+)
+
+// AdaptiveThreshold segments a grayscale image using a threshold computed separately for every pixel from its
+// blockSize * blockSize neighbourhood, so images with uneven lighting can be binarized. The block size has to be an
+// odd number bigger than 1. C is subtracted from the local mean, a positive C keeps the flat areas on the background
+// side. The pixels above the local threshold are the foreground. Methods: ThreshBinary, ThreshBinaryInv.
+// Both methods clip the neighbourhoods at the edges of the image.
+// Example of usage:
+//
+//	res, err := threshold.AdaptiveThreshold(img, threshold.AdaptiveGaussian, 15, 5, threshold.ThreshBinary)
+func AdaptiveThreshold(img *image.Gray, method AdaptiveMethod, blockSize int, c float64,
+	thresholdMethod Method) (*image.Gray, error) {
+	return AdaptiveThresholdCtx(context.Background(), img, method, blockSize, c, thresholdMethod, nil)
+}
+
+// AdaptiveThresholdCtx is AdaptiveThreshold which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func AdaptiveThresholdCtx(ctx context.Context, img *image.Gray, method AdaptiveMethod, blockSize int, c float64,
+	thresholdMethod Method, opts *utils.Options) (*image.Gray, error) {
+	if err := validateLocal(blockSize, thresholdMethod); err != nil {
+		return nil, err
+	}
+	switch method {
+	case AdaptiveMean:
+		integral := newIntegralImages(img)
+		return localThreshold(ctx, img, thresholdMethod, opts, func(x, y int) float64 {
+			mean, _ := integral.meanAndDeviation(x, y, blockSize/2)
+			return mean - c
+		})
+	case AdaptiveGaussian:
+		means, err := gaussianMeans(ctx, img, blockSize, opts)
+		if err != nil {
+			return nil, err
+		}
+		return localThreshold(ctx, img, thresholdMethod, opts, func(x, y int) float64 {
+			return float64(means.Pix[y*means.Width+x]) - c
+		})
+	}
+	return nil, errors.New("invalid adaptive method")
+}
+
+// NiblackThreshold segments a grayscale image using Niblack's local method: the threshold of every pixel is
+// mean + k * standard deviation of its blockSize * blockSize neighbourhood. k is usually around -0.2 for dark text on a
+// bright background. The means and deviations are computed in constant time per pixel using integral images, the
+// neighbourhoods are clipped at the edges of the image. Methods: ThreshBinary, ThreshBinaryInv.
+// Example of usage:
+//
+//	res, err := threshold.NiblackThreshold(img, 25, -0.2, threshold.ThreshBinary)
+func NiblackThreshold(img *image.Gray, blockSize int, k float64, method Method) (*image.Gray, error) {
+	return NiblackThresholdCtx(context.Background(), img, blockSize, k, method, nil)
+}
+
+// NiblackThresholdCtx is NiblackThreshold which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func NiblackThresholdCtx(ctx context.Context, img *image.Gray, blockSize int, k float64,
+	method Method, opts *utils.Options) (*image.Gray, error) {
+	if err := validateLocal(blockSize, method); err != nil {
+		return nil, err
+	}
+	integral := newIntegralImages(img)
+	return localThreshold(ctx, img, method, opts, func(x, y int) float64 {
+		mean, deviation := integral.meanAndDeviation(x, y, blockSize/2)
+		return mean + k*deviation
+	})
+}
+
+// SauvolaThreshold segments a grayscale image using Sauvola's local method: the threshold of every pixel is
+// mean * (1 + k * (standard deviation / r - 1)) of its blockSize * blockSize neighbourhood, where r is the dynamic
+// range of the standard deviation (128 for 8 bit images). Compared to Niblack, the threshold drops on flat areas, which
+// suppresses the noise of the background of documents. The usual k is between 0.2 and 0.5. The means and deviations
+// are computed in constant time per pixel using integral images, the neighbourhoods are clipped at the edges of the
+// image. Methods: ThreshBinary, ThreshBinaryInv.
+// Example of usage:
+//
+//	res, err := threshold.SauvolaThreshold(img, 25, 0.34, 128, threshold.ThreshBinary)
+func SauvolaThreshold(img *image.Gray, blockSize int, k float64, r float64, method Method) (*image.Gray, error) {
+	return SauvolaThresholdCtx(context.Background(), img, blockSize, k, r, method, nil)
+}
+
+// SauvolaThresholdCtx is SauvolaThreshold which can be aborted using ctx. ctx.Err() is returned if the context was
+// cancelled.
+func SauvolaThresholdCtx(ctx context.Context, img *image.Gray, blockSize int, k float64, r float64,
+	method Method, opts *utils.Options) (*image.Gray, error) {
+	if err := validateLocal(blockSize, method); err != nil {
+		return nil, err
+	}
+	if r <= 0 {
+		return nil, errors.New("dynamic range must be bigger then 0")
+	}
+	integral := newIntegralImages(img)
+	return localThreshold(ctx, img, method, opts, func(x, y int) float64 {
+		mean, deviation := integral.meanAndDeviation(x, y, blockSize/2)
+		return mean * (1 + k*(deviation/r-1))
+	})
+}
+
+// -------------------------------------------------------------------------------------------------------
+func validateLocal(blockSize int, method Method) error {
+	if blockSize < 3 || blockSize%2 == 0 {
+		return errors.New("block size must be an odd number bigger then 1")
+	}
+	if method != ThreshBinary && method != ThreshBinaryInv {
+		return errors.New("invalid threshold method")
+	}
+	return nil
+}
+
+// localThreshold marks the pixels above their local threshold as foreground.
+func localThreshold(ctx context.Context, img *image.Gray, method Method, opts *utils.Options,
+	thresholdAt func(x, y int) float64) (*image.Gray, error) {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	foreground, background := utils.MaxUint8, uint8(utils.MinUint8)
+	if method == ThreshBinaryInv {
+		foreground, background = background, foreground
+	}
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	err := utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		if float64(img.Pix[img.PixOffset(x+offset.X, y+offset.Y)]) > thresholdAt(x, y) {
+			res.Pix[y*res.Stride+x] = foreground
+		} else {
+			res.Pix[y*res.Stride+x] = background
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// gaussianMeans computes the Gaussian weighted mean of the blockSize * blockSize neighbourhood of every pixel, clipped
+// to the image: the pixels outside of the image are left out and every sum is divided by the weights of the pixels
+// inside of the image. The Gaussian is separable, so it is applied as a horizontal and a vertical 1D pass.
+func gaussianMeans(ctx context.Context, img *image.Gray, blockSize int,
+	opts *utils.Options) (*utils.Float32Image, error) {
+	// the sigma derived from the block size, as in OpenCV
+	sigma := 0.3*(float64(blockSize-1)*0.5-1) + 0.8
+	radius := blockSize / 2
+	weights := make([]float64, blockSize)
+	for i := range weights {
+		d := float64(i - radius)
+		weights[i] = math.Exp(-d * d / (2 * sigma * sigma))
+	}
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	means, err := utils.NewFloat32Image(size.X, size.Y, 1)
+	if err != nil {
+		return nil, err
+	}
+	weightsX, weightsY := clippedWeights(weights, size.X), clippedWeights(weights, size.Y)
+	horizontal := make([]float64, size.X*size.Y)
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		var sum float64
+		for k, w := range weights {
+			if wx := x + k - radius; wx >= 0 && wx < size.X {
+				sum += w * float64(img.Pix[img.PixOffset(wx+offset.X, y+offset.Y)])
+			}
+		}
+		horizontal[y*size.X+x] = sum / weightsX[x]
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), size, func(x, y int) {
+		var sum float64
+		for k, w := range weights {
+			if wy := y + k - radius; wy >= 0 && wy < size.Y {
+				sum += w * horizontal[wy*size.X+x]
+			}
+		}
+		means.Pix[y*size.X+x] = float32(sum / weightsY[y])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return means, nil
+}
+
+// clippedWeights returns the sum of the weights which fall inside of a line of n pixels for every position of the line,
+// the weights being centered on the position.
+func clippedWeights(weights []float64, n int) []float64 {
+	radius := len(weights) / 2
+	res := make([]float64, n)
+	for i := range res {
+		for k, w := range weights {
+			if j := i + k - radius; j >= 0 && j < n {
+				res[i] += w
+			}
+		}
+	}
+	return res
+}
+
+// integralImages holds the sums of the pixels and of their squares above and left of every position: sum[y*stride+x]
+// is the sum of the pixels of the rectangle (0, 0)-(x, y), the bottom and right edges excluded.
+type integralImages struct {
+	sum    []uint64
+	sqSum  []uint64
+	stride int
+	size   image.Point
+}
+
+func newIntegralImages(img *image.Gray) *integralImages {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	stride := size.X + 1
+	integral := &integralImages{
+		sum:    make([]uint64, stride*(size.Y+1)),
+		sqSum:  make([]uint64, stride*(size.Y+1)),
+		stride: stride,
+		size:   size,
+	}
+	for y := 0; y < size.Y; y++ {
+		var rowSum, rowSqSum uint64
+		for x := 0; x < size.X; x++ {
+			v := uint64(img.Pix[img.PixOffset(x+offset.X, y+offset.Y)])
+			rowSum += v
+			rowSqSum += v * v
+			i := (y+1)*stride + x + 1
+			integral.sum[i] = integral.sum[i-stride] + rowSum
+			integral.sqSum[i] = integral.sqSum[i-stride] + rowSqSum
+		}
+	}
+	return integral
+}
+
+// meanAndDeviation returns the mean and the standard deviation of the square window with the given radius around
+// {x, y}, clipped to the image.
+func (ii *integralImages) meanAndDeviation(x, y, radius int) (float64, float64) {
+	x0, y0 := utils.ClampInt(x-radius, 0, ii.size.X), utils.ClampInt(y-radius, 0, ii.size.Y)
+	x1, y1 := utils.ClampInt(x+radius+1, 0, ii.size.X), utils.ClampInt(y+radius+1, 0, ii.size.Y)
+	rect := func(values []uint64) float64 {
+		return float64(values[y1*ii.stride+x1] + values[y0*ii.stride+x0] - values[y0*ii.stride+x1] -
+			values[y1*ii.stride+x0])
+	}
+	n := float64((x1 - x0) * (y1 - y0))
+	mean := rect(ii.sum) / n
+	variance := rect(ii.sqSum)/n - mean*mean
+	return mean, math.Sqrt(math.Max(0, variance))
+}
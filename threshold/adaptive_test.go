@@ -0,0 +1,231 @@
+package threshold
+
+import (
+	"context"
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/ernyoke/imger/utils"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+// unevenDocument returns a page whose background brightens from left to right, with dark dots on it, and the
+// expected binarized image (the dots are 0, the page 255).
+func unevenDocument() (*image.Gray, *image.Gray) {
+	img := image.NewGray(image.Rect(0, 0, 40, 20))
+	expected := image.NewGray(img.Bounds())
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			background := uint8(60 + 4*x)
+			if x%8 == 3 && y%6 == 2 {
+				img.Pix[y*img.Stride+x] = background - 40
+			} else {
+				img.Pix[y*img.Stride+x] = background
+				expected.Pix[y*expected.Stride+x] = 255
+			}
+		}
+	}
+	return img, expected
+}
+
+func Test_AdaptiveThreshold_UnevenLighting(t *testing.T) {
+	img, expected := unevenDocument()
+	// a global threshold can not separate the dots on the bright side from the dark side of the page
+	global, _ := Threshold(img, 100, ThreshBinary)
+	if global.Pix[0] != 0 || global.Pix[2*global.Stride+35] != 255 {
+		t.Fatal("Expected the global threshold to fail on the test image")
+	}
+	for _, method := range []AdaptiveMethod{AdaptiveMean, AdaptiveGaussian} {
+		res, err := AdaptiveThreshold(img, method, 7, 15, ThreshBinary)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		utils.CompareGrayImages(t, expected, res)
+
+		inverse, err := AdaptiveThreshold(img, method, 7, 15, ThreshBinaryInv)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for i := range expected.Pix {
+			if inverse.Pix[i] != 255-expected.Pix[i] {
+				t.Fatalf("Expected the inverse of the binary result at %d", i)
+			}
+		}
+	}
+}
+
+func Test_AdaptiveThreshold_Cropped(t *testing.T) {
+	img, expected := unevenDocument()
+	rect := image.Rect(5, 3, 30, 17)
+	for _, method := range []AdaptiveMethod{AdaptiveMean, AdaptiveGaussian} {
+		res, err := AdaptiveThreshold(img.SubImage(rect).(*image.Gray), method, 5, 15, ThreshBinary)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		utils.CompareGrayImages(t, expected.SubImage(rect).(*image.Gray), res)
+	}
+}
+
+func Test_LocalThreshold_InvalidParameters(t *testing.T) {
+	img, _ := unevenDocument()
+	for _, blockSize := range []int{-3, 0, 1, 4} {
+		if _, err := AdaptiveThreshold(img, AdaptiveMean, blockSize, 0, ThreshBinary); err == nil {
+			t.Errorf("Expected error for block size %d", blockSize)
+		}
+		if _, err := NiblackThreshold(img, blockSize, -0.2, ThreshBinary); err == nil {
+			t.Errorf("Expected error for block size %d", blockSize)
+		}
+		if _, err := SauvolaThreshold(img, blockSize, 0.3, 128, ThreshBinary); err == nil {
+			t.Errorf("Expected error for block size %d", blockSize)
+		}
+	}
+	if _, err := AdaptiveThreshold(img, AdaptiveMethod(42), 3, 0, ThreshBinary); err == nil {
+		t.Error("Expected error for invalid adaptive method")
+	}
+	if _, err := AdaptiveThreshold(img, AdaptiveMean, 3, 0, ThreshTrunc); err == nil {
+		t.Error("Expected error for unsupported threshold method")
+	}
+	if _, err := SauvolaThreshold(img, 3, 0.3, 0, ThreshBinary); err == nil {
+		t.Error("Expected error for dynamic range 0")
+	}
+}
+
+func Test_NiblackSauvola_FlatBackground(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 30, 30))
+	for i := range img.Pix {
+		img.Pix[i] = 180
+	}
+	for y := 12; y < 16; y++ {
+		for x := 12; x < 16; x++ {
+			img.Pix[y*img.Stride+x] = 40
+		}
+	}
+	niblack, err := NiblackThreshold(img, 9, -0.2, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sauvola, err := SauvolaThreshold(img, 9, 0.3, 128, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, res := range []*image.Gray{niblack, sauvola} {
+		if res.Pix[13*res.Stride+13] != 0 || res.Pix[13*res.Stride+10] != 255 {
+			t.Errorf("Expected the dark square to be background and its surrounding foreground")
+		}
+	}
+	// on flat areas the threshold of Niblack is the mean itself, while Sauvola lowers it
+	if niblack.Pix[0] != 0 {
+		t.Errorf("Expected Niblack to mark the flat background as background - actual %d", niblack.Pix[0])
+	}
+	if sauvola.Pix[0] != 255 {
+		t.Errorf("Expected Sauvola to mark the flat background as foreground - actual %d", sauvola.Pix[0])
+	}
+}
+
+func Test_IntegralImages_MeanAndDeviation(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	img := image.NewGray(image.Rect(0, 0, 13, 9))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.Intn(256))
+	}
+	integral := newIntegralImages(img)
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 13; x++ {
+			var sum, sqSum, n float64
+			for wy := y - 2; wy <= y+2; wy++ {
+				for wx := x - 2; wx <= x+2; wx++ {
+					if wx < 0 || wy < 0 || wx >= 13 || wy >= 9 {
+						continue
+					}
+					v := float64(img.Pix[wy*img.Stride+wx])
+					sum, sqSum, n = sum+v, sqSum+v*v, n+1
+				}
+			}
+			mean := sum / n
+			deviation := math.Sqrt(sqSum/n - mean*mean)
+			actualMean, actualDeviation := integral.meanAndDeviation(x, y, 2)
+			if math.Abs(mean-actualMean) > 1e-9 || math.Abs(deviation-actualDeviation) > 1e-9 {
+				t.Fatalf("At (%d, %d): expected mean %f, deviation %f - actual mean %f, deviation %f", x, y, mean,
+					deviation, actualMean, actualDeviation)
+			}
+		}
+	}
+}
+
+func Test_GaussianMeans_ClippedWindows(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	img := image.NewGray(image.Rect(0, 0, 13, 9))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(r.Intn(256))
+	}
+	means, err := gaussianMeans(context.Background(), img, 5, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the sigma of OpenCV for a block size of 5
+	sigma := 0.3*((5-1)*0.5-1) + 0.8
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 13; x++ {
+			var sum, weightSum float64
+			for wy := y - 2; wy <= y+2; wy++ {
+				for wx := x - 2; wx <= x+2; wx++ {
+					if wx < 0 || wy < 0 || wx >= 13 || wy >= 9 {
+						continue
+					}
+					dx, dy := float64(wx-x), float64(wy-y)
+					w := math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+					sum, weightSum = sum+w*float64(img.Pix[wy*img.Stride+wx]), weightSum+w
+				}
+			}
+			expected := sum / weightSum
+			if actual := float64(means.Pix[y*means.Width+x]); math.Abs(expected-actual) > 1e-3 {
+				t.Fatalf("At (%d, %d): expected mean %f - actual mean %f", x, y, expected, actual)
+			}
+		}
+	}
+}
+
+func Test_AdaptiveThresholdGaussian_Uniform(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 12, 10))
+	for i := range img.Pix {
+		img.Pix[i] = 100
+	}
+	// the mean is exactly 100 everywhere, also in the corners, so a half gray level decides the result
+	for _, c := range []struct {
+		c        float64
+		expected uint8
+	}{{-0.5, 0}, {0.5, 255}} {
+		res, err := AdaptiveThreshold(img, AdaptiveGaussian, 7, c.c, ThreshBinary)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for i, v := range res.Pix {
+			if v != c.expected {
+				t.Fatalf("Expected %d with C %f at %d - actual %d", c.expected, c.c, i, v)
+			}
+		}
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_AdaptiveThresholdGaussian(t *testing.T) {
+	gray := setupTestCaseOtsu(t)
+	thresh, err := AdaptiveThreshold(gray, AdaptiveGaussian, 15, 5, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tearDownTestCase(t, thresh, "../res/threshold/adaptiveGaussian.png")
+}
+
+func Test_Acceptance_SauvolaThreshold(t *testing.T) {
+	gray := setupTestCaseOtsu(t)
+	thresh, err := SauvolaThreshold(gray, 25, 0.34, 128, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tearDownTestCase(t, thresh, "../res/threshold/sauvola.png")
+}
@@ -0,0 +1,395 @@
+package threshold
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"github.com/ernyoke/imger/histogram"
+)
+
+// Every XxxLevel function returns the last gray level of the darker class, like OtsuLevel, and every XxxThreshold
+// function passes its level to Threshold the same way OtsuThreshold does. The algorithms follow the Auto Threshold
+// plugin of ImageJ.
+
+// TriangleThreshold returns a grayscale image which was segmented using the level found by the triangle method.
+// Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
+func TriangleThreshold(img *image.Gray, method Method) (*image.Gray, error) {
+	return Threshold(img, TriangleLevel(img), method)
+}
+
+// TriangleLevel returns the threshold value found by the triangle method: a line is drawn from the peak of the
+// histogram to the end of its longer tail, and the level is where the histogram is farthest below this line. It works
+// well for images with one dominant peak, for example a few bright objects on a large dark background.
+// Example of usage:
+//
+//	level := threshold.TriangleLevel(img)
+func TriangleLevel(img *image.Gray) uint8 {
+	hist := histogramFloat(img)
+	first, last, ok := histogramRange(hist)
+	if !ok {
+		return 0
+	}
+	if first == last {
+		return uint8(first)
+	}
+	// the line starts one bin outside of the histogram
+	if first > 0 {
+		first--
+	}
+	if last < len(hist)-1 {
+		last++
+	}
+	peak := 0
+	for i, bin := range hist {
+		if bin > hist[peak] {
+			peak = i
+		}
+	}
+	// the algorithm searches left of the peak, so the histogram is flipped if its longer tail is on the right
+	inverted := peak-first < last-peak
+	if inverted {
+		for i, j := 0, len(hist)-1; i < j; i, j = i+1, j-1 {
+			hist[i], hist[j] = hist[j], hist[i]
+		}
+		first, peak = len(hist)-1-last, len(hist)-1-peak
+	}
+	if first == peak {
+		return uint8(first)
+	}
+	// the normal of the line from {first, hist[first]} to {peak, hist[peak]}
+	nx, ny := hist[peak], float64(first-peak)
+	norm := math.Hypot(nx, ny)
+	nx, ny = nx/norm, ny/norm
+	d := nx*float64(first) + ny*hist[first]
+	split, splitDistance := first, 0.0
+	for i := first + 1; i <= peak; i++ {
+		if distance := nx*float64(i) + ny*hist[i] - d; distance > splitDistance {
+			split, splitDistance = i, distance
+		}
+	}
+	if split > 0 {
+		split--
+	}
+	if inverted {
+		return uint8(len(hist) - 1 - split)
+	}
+	return uint8(split)
+}
+
+// YenThreshold returns a grayscale image which was segmented using the level found by Yen's method.
+// Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
+func YenThreshold(img *image.Gray, method Method) (*image.Gray, error) {
+	return Threshold(img, YenLevel(img), method)
+}
+
+// YenLevel returns the threshold value found by Yen's method, which maximizes the entropic correlation of the pixels
+// below and above the level.
+// Example of usage:
+//
+//	level := threshold.YenLevel(img)
+func YenLevel(img *image.Gray) uint8 {
+	hist := normalizedHistogram(img)
+	var cumulative, cumulativeSq [256]float64
+	var aboveSq [256]float64
+	for i, p := range hist {
+		cumulative[i], cumulativeSq[i] = p, p*p
+		if i > 0 {
+			cumulative[i] += cumulative[i-1]
+			cumulativeSq[i] += cumulativeSq[i-1]
+		}
+	}
+	for i := len(hist) - 2; i >= 0; i-- {
+		aboveSq[i] = aboveSq[i+1] + hist[i+1]*hist[i+1]
+	}
+	level, maxCriterion := 0, math.Inf(-1)
+	for i := range hist {
+		var criterion float64
+		if product := cumulativeSq[i] * aboveSq[i]; product > 0 {
+			criterion -= math.Log(product)
+		}
+		if product := cumulative[i] * (1 - cumulative[i]); product > 0 {
+			criterion += 2 * math.Log(product)
+		}
+		if criterion > maxCriterion {
+			level, maxCriterion = i, criterion
+		}
+	}
+	return uint8(level)
+}
+
+// LiThreshold returns a grayscale image which was segmented using the level found by Li's minimum cross entropy
+// method.
+// Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
+func LiThreshold(img *image.Gray, method Method) (*image.Gray, error) {
+	return Threshold(img, LiLevel(img), method)
+}
+
+// LiLevel returns the threshold value found by Li's method, which minimizes the cross entropy between the image and
+// its segmented version. The level is searched iteratively, starting from the mean of the image.
+// Example of usage:
+//
+//	level := threshold.LiLevel(img)
+func LiLevel(img *image.Gray) uint8 {
+	hist := histogramFloat(img)
+	if _, _, ok := histogramRange(hist); !ok {
+		return 0
+	}
+	next, _ := classMean(hist, 0, len(hist)-1)
+	level := 0
+	for i := 0; i < maxIterations; i++ {
+		level = int(math.Round(next))
+		meanBackground, nBackground := classMean(hist, 0, level)
+		meanForeground, nForeground := classMean(hist, level+1, len(hist)-1)
+		if nBackground == 0 || nForeground == 0 || meanBackground == 0 {
+			break
+		}
+		previous := next
+		next = math.Round((meanForeground - meanBackground) /
+			(math.Log(meanForeground) - math.Log(meanBackground)))
+		if math.Abs(next-previous) <= 0.5 {
+			break
+		}
+	}
+	return uint8(level)
+}
+
+// HuangThreshold returns a grayscale image which was segmented using the level found by Huang's fuzzy thresholding
+// method.
+// Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
+func HuangThreshold(img *image.Gray, method Method) (*image.Gray, error) {
+	return Threshold(img, HuangLevel(img), method)
+}
+
+// HuangLevel returns the threshold value found by Huang's method, which minimizes the fuzziness of the segmentation:
+// the membership of a pixel to its class decreases with its distance from the mean of the class.
+// Example of usage:
+//
+//	level := threshold.HuangLevel(img)
+func HuangLevel(img *image.Gray) uint8 {
+	hist := histogramFloat(img)
+	first, last, ok := histogramRange(hist)
+	if !ok {
+		return 0
+	}
+	if first == last {
+		return uint8(first)
+	}
+	// the entropy of the membership for every distance from the mean of a class
+	c := float64(last - first)
+	entropy := make([]float64, last-first+1)
+	for i := 1; i < len(entropy); i++ {
+		mu := 1 / (1 + float64(i)/c)
+		entropy[i] = -mu*math.Log(mu) - (1-mu)*math.Log(1-mu)
+	}
+	fuzziness := func(from, to int) float64 {
+		mean, _ := classMean(hist, from, to)
+		mu := int(math.Round(mean))
+		var sum float64
+		for i := from; i <= to; i++ {
+			sum += entropy[abs(i-mu)] * hist[i]
+		}
+		return sum
+	}
+	level, minFuzziness := first, math.Inf(1)
+	for t := first; t < last; t++ {
+		if f := fuzziness(first, t) + fuzziness(t+1, last); f < minFuzziness {
+			level, minFuzziness = t, f
+		}
+	}
+	return uint8(level)
+}
+
+// IsoDataThreshold returns a grayscale image which was segmented using the level found by the IsoData method.
+// Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
+func IsoDataThreshold(img *image.Gray, method Method) (*image.Gray, error) {
+	return Threshold(img, IsoDataLevel(img), method)
+}
+
+// IsoDataLevel returns the threshold value found by the iterative intermeans (IsoData, Ridler-Calvard) method: starting
+// from the mean of the image, the level is moved to the average of the means of the pixels below and above it, until
+// it does not change anymore.
+// Example of usage:
+//
+//	level := threshold.IsoDataLevel(img)
+func IsoDataLevel(img *image.Gray) uint8 {
+	hist := histogramFloat(img)
+	mean, n := classMean(hist, 0, len(hist)-1)
+	if n == 0 {
+		return 0
+	}
+	level := int(mean)
+	for i := 0; i < maxIterations; i++ {
+		meanBackground, nBackground := classMean(hist, 0, level)
+		meanForeground, nForeground := classMean(hist, level+1, len(hist)-1)
+		if nBackground == 0 || nForeground == 0 {
+			break
+		}
+		next := int((meanBackground + meanForeground) / 2)
+		if next == level {
+			break
+		}
+		level = next
+	}
+	return uint8(level)
+}
+
+// MultiOtsuLevels generalizes Otsu's method to more than two classes: it returns the classes-1 levels which maximize
+// the variance between the classes, in ascending order. Every level is the last gray level of its class. The search
+// is exhaustive over the histogram, using dynamic programming.
+// Example of usage:
+//
+//	levels, err := threshold.MultiOtsuLevels(img, 3)
+func MultiOtsuLevels(img *image.Gray, classes int) ([]uint8, error) {
+	if classes < 2 || classes > 256 {
+		return nil, errors.New("number of classes must be between 2 and 256")
+	}
+	hist := histogramFloat(img)
+	// prefix sums of the weights and of the weighted gray levels
+	var weights, sums [257]float64
+	for i, bin := range hist {
+		weights[i+1] = weights[i] + bin
+		sums[i+1] = sums[i] + float64(i)*bin
+	}
+	// the contribution of the class of the bins from..to-1 to the variance between the classes
+	contribution := func(from, to int) float64 {
+		w := weights[to] - weights[from]
+		if w == 0 {
+			return 0
+		}
+		s := sums[to] - sums[from]
+		return s * s / w
+	}
+	// best[k][j] is the best score of splitting the bins 0..j-1 into k+1 classes, split[k][j] is the start of the
+	// last class of that split
+	best := make([][257]float64, classes)
+	split := make([][257]int, classes)
+	for j := 1; j <= 256; j++ {
+		best[0][j] = contribution(0, j)
+	}
+	for k := 1; k < classes; k++ {
+		for j := k + 1; j <= 256; j++ {
+			best[k][j] = math.Inf(-1)
+			for i := k; i < j; i++ {
+				if score := best[k-1][i] + contribution(i, j); score > best[k][j] {
+					best[k][j], split[k][j] = score, i
+				}
+			}
+		}
+	}
+	levels := make([]uint8, classes-1)
+	end := 256
+	for k := classes - 1; k > 0; k-- {
+		end = split[k][end]
+		levels[k-1] = uint8(end - 1)
+	}
+	return levels, nil
+}
+
+// MultiOtsuThreshold returns a grayscale image which was quantized into the given number of gray bands using the
+// levels found by MultiOtsuLevels.
+// Example of usage:
+//
+//	res, err := threshold.MultiOtsuThreshold(img, 3)
+func MultiOtsuThreshold(img *image.Gray, classes int) (*image.Gray, error) {
+	levels, err := MultiOtsuLevels(img, classes)
+	if err != nil {
+		return nil, err
+	}
+	return Quantize(img, levels)
+}
+
+// Quantize maps a grayscale image into len(levels)+1 gray bands: the pixels up to levels[0] become 0, the pixels
+// above the last level become 255, and the bands between them get evenly spaced values. The levels have to be in
+// strictly ascending order.
+// Example of usage:
+//
+//	res, err := threshold.Quantize(img, []uint8{85, 170})
+func Quantize(img *image.Gray, levels []uint8) (*image.Gray, error) {
+	if len(levels) == 0 {
+		return nil, errors.New("at least one level must be given")
+	}
+	for i := 1; i < len(levels); i++ {
+		if levels[i] <= levels[i-1] {
+			return nil, errors.New("levels must be in strictly ascending order")
+		}
+	}
+	var lut [256]uint8
+	band := 0
+	for i := range lut {
+		for band < len(levels) && i > int(levels[band]) {
+			band++
+		}
+		lut[i] = uint8(math.Round(float64(band) * 255 / float64(len(levels))))
+	}
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	res := image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			res.Pix[y*res.Stride+x] = lut[img.Pix[img.PixOffset(x+offset.X, y+offset.Y)]]
+		}
+	}
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+// maxIterations limits the iterative level searches, which converge in a few steps on real images.
+const maxIterations = 256
+
+func histogramFloat(img *image.Gray) []float64 {
+	hist := histogram.HistogramGray(img)
+	res := make([]float64, len(hist))
+	for i, bin := range hist {
+		res[i] = float64(bin)
+	}
+	return res
+}
+
+func normalizedHistogram(img *image.Gray) []float64 {
+	hist := histogramFloat(img)
+	var total float64
+	for _, bin := range hist {
+		total += bin
+	}
+	if total > 0 {
+		for i := range hist {
+			hist[i] /= total
+		}
+	}
+	return hist
+}
+
+// histogramRange returns the first and the last non-empty bins, ok is false for an empty histogram.
+func histogramRange(hist []float64) (int, int, bool) {
+	first, last := -1, -1
+	for i, bin := range hist {
+		if bin > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	return first, last, first >= 0
+}
+
+// classMean returns the mean gray level and the number of pixels of the bins from..to (inclusive).
+func classMean(hist []float64, from, to int) (float64, float64) {
+	var sum, n float64
+	for i := from; i <= to; i++ {
+		sum += float64(i) * hist[i]
+		n += hist[i]
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return sum / n, n
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
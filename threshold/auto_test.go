@@ -0,0 +1,181 @@
+package threshold
+
+import (
+	"image"
+	"testing"
+)
+
+// ---------------------------------Unit tests--------------------------------------
+
+// imageFromHistogram creates an image which has the given number of pixels of every gray level.
+func imageFromHistogram(counts map[uint8]int) *image.Gray {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	img := image.NewGray(image.Rect(0, 0, total, 1))
+	i := 0
+	for level := 0; level < 256; level++ {
+		for c := 0; c < counts[uint8(level)]; c++ {
+			img.Pix[i] = uint8(level)
+			i++
+		}
+	}
+	return img
+}
+
+// bimodal has two peaks of the same shape around 60 and 190.
+func bimodal() *image.Gray {
+	counts := map[uint8]int{}
+	for d := 0; d <= 20; d++ {
+		counts[uint8(60-d)] += 21 - d
+		counts[uint8(60+d)] += 21 - d
+		counts[uint8(190-d)] += 21 - d
+		counts[uint8(190+d)] += 21 - d
+	}
+	return imageFromHistogram(counts)
+}
+
+func Test_Levels_Bimodal(t *testing.T) {
+	img := bimodal()
+	levels := map[string]func(*image.Gray) uint8{
+		"Otsu":     OtsuLevel,
+		"Triangle": TriangleLevel,
+		"Yen":      YenLevel,
+		"Li":       LiLevel,
+		"Huang":    HuangLevel,
+		"IsoData":  IsoDataLevel,
+	}
+	for name, level := range levels {
+		if l := level(img); l < 80 || l >= 170 {
+			t.Errorf("%s: expected a level between the peaks - actual %d", name, l)
+		}
+	}
+}
+
+func Test_Levels_TwoValues(t *testing.T) {
+	img := imageFromHistogram(map[uint8]int{50: 10, 200: 10})
+	// the means of the two classes are 50 and 200
+	if level := IsoDataLevel(img); level != 125 {
+		t.Errorf("IsoData: expected level 125 - actual %d", level)
+	}
+	// (200 - 50) / (ln(200) - ln(50)) = 108.2
+	if level := LiLevel(img); level != 108 {
+		t.Errorf("Li: expected level 108 - actual %d", level)
+	}
+	// every level between the values gives crisp classes, the first one is returned
+	if level := HuangLevel(img); level != 50 {
+		t.Errorf("Huang: expected level 50 - actual %d", level)
+	}
+	// the line from the peak at 50 to the end of the longer tail at 201 is the farthest from the empty bin at 52
+	if level := TriangleLevel(img); level != 52 {
+		t.Errorf("Triangle: expected level 52 - actual %d", level)
+	}
+	thresh, err := TriangleThreshold(img, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if thresh.Pix[0] != 0 || thresh.Pix[19] != 255 {
+		t.Errorf("Expected the two values to be separated - actual %v", thresh.Pix)
+	}
+}
+
+func Test_Levels_Degenerate(t *testing.T) {
+	flat := imageFromHistogram(map[uint8]int{0: 5})
+	empty := image.NewGray(image.Rect(0, 0, 0, 0))
+	for _, img := range []*image.Gray{flat, empty} {
+		for _, level := range []func(*image.Gray) uint8{TriangleLevel, YenLevel, LiLevel, HuangLevel, IsoDataLevel} {
+			if l := level(img); l != 0 {
+				t.Errorf("Expected level 0 - actual %d", l)
+			}
+		}
+	}
+	single := imageFromHistogram(map[uint8]int{77: 3})
+	for _, level := range []func(*image.Gray) uint8{TriangleLevel, HuangLevel} {
+		if l := level(single); l != 77 {
+			t.Errorf("Expected the only gray level 77 - actual %d", l)
+		}
+	}
+}
+
+func Test_MultiOtsuLevels(t *testing.T) {
+	img := imageFromHistogram(map[uint8]int{20: 5, 21: 5, 100: 4, 110: 8, 230: 6, 240: 2})
+	levels, err := MultiOtsuLevels(img, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(levels) != 2 || levels[0] < 21 || levels[0] >= 100 || levels[1] < 110 || levels[1] >= 230 {
+		t.Errorf("Expected levels separating the three groups - actual %v", levels)
+	}
+	// with two classes it is the same as Otsu's method
+	levels, err = MultiOtsuLevels(bimodal(), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if otsu := OtsuLevel(bimodal()); len(levels) != 1 || levels[0] != otsu {
+		t.Errorf("Expected level %d - actual %v", otsu, levels)
+	}
+	for _, classes := range []int{-1, 0, 1, 257} {
+		if _, err := MultiOtsuLevels(img, classes); err == nil {
+			t.Errorf("Expected error for %d classes", classes)
+		}
+	}
+}
+
+func Test_Quantize(t *testing.T) {
+	img := imageFromHistogram(map[uint8]int{0: 1, 10: 1, 11: 1, 100: 1, 101: 1, 255: 1})
+	res, err := Quantize(img, []uint8{10, 100})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []uint8{0, 0, 128, 128, 255, 255}
+	for i, v := range expected {
+		if res.Pix[i] != v {
+			t.Fatalf("Expected %v - actual %v", expected, res.Pix)
+		}
+	}
+	if _, err := Quantize(img, nil); err == nil {
+		t.Error("Expected error for no levels")
+	}
+	if _, err := Quantize(img, []uint8{100, 100}); err == nil {
+		t.Error("Expected error for levels which are not ascending")
+	}
+
+	multi, err := MultiOtsuThreshold(imageFromHistogram(map[uint8]int{20: 3, 120: 3, 220: 3}), 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = []uint8{0, 0, 0, 128, 128, 128, 255, 255, 255}
+	for i, v := range expected {
+		if multi.Pix[i] != v {
+			t.Fatalf("Expected %v - actual %v", expected, multi.Pix)
+		}
+	}
+}
+
+// -----------------------------Acceptance tests------------------------------------
+
+func Test_Acceptance_LiThreshold(t *testing.T) {
+	gray := setupTestCaseOtsu(t)
+	thresh, err := LiThreshold(gray, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tearDownTestCase(t, thresh, "../res/threshold/liThreshBin.jpg")
+}
+
+func Test_Acceptance_MultiOtsuThreshold(t *testing.T) {
+	gray := setupTestCaseOtsu(t)
+	levels, err := MultiOtsuLevels(gray, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if otsu := OtsuLevel(gray); levels[0] != otsu {
+		t.Errorf("Expected the Otsu level %d - actual %d", otsu, levels[0])
+	}
+	thresh, err := MultiOtsuThreshold(gray, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tearDownTestCase(t, thresh, "../res/threshold/multiOtsu4.jpg")
+}
@@ -158,7 +158,35 @@ func Threshold16(img *image.Gray16, t uint16, method Method) (*image.Gray16, err
 // Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
 // More info about Otsu's method: https://en.wikipedia.org/wiki/Otsu%27s_method
 func OtsuThreshold(img *image.Gray, method Method) (*image.Gray, error) {
-	return Threshold(img, otsuThresholdValue(img), method)
+	return Threshold(img, OtsuLevel(img), method)
+}
+
+// OtsuLevel returns the threshold value found by Otsu's method: the level which maximizes the variance between the
+// pixels below and above it.
+// Example of usage:
+//
+//	level := threshold.OtsuLevel(img)
+func OtsuLevel(img *image.Gray) uint8 {
+	return otsuThresholdValue(img)
+}
+
+// OtsuThreshold16 returns a 16 bit grayscale image which was segmented using Otsu's adaptive thresholding method.
+// Methods: ThreshBinary, ThreshBinaryInv, ThreshTrunc, ThreshToZero, ThreshToZeroInv
+// Example of usage:
+//
+//	res, err := threshold.OtsuThreshold16(img, threshold.ThreshBinary)
+func OtsuThreshold16(img *image.Gray16, method Method) (*image.Gray16, error) {
+	return Threshold16(img, OtsuLevel16(img), method)
+}
+
+// OtsuLevel16 returns the threshold value of a 16 bit grayscale image found by Otsu's method. Every 16 bit value is
+// evaluated, using a histogram with histogram.MaxBins16 bins.
+// Example of usage:
+//
+//	level := threshold.OtsuLevel16(img)
+func OtsuLevel16(img *image.Gray16) uint16 {
+	hist, _ := histogram.HistogramGray16(img, histogram.MaxBins16)
+	return uint16(otsuLevel(hist))
 }
 
 // -------------------------------------------------------------------------------------------------------
@@ -182,11 +210,15 @@ func threshold16(img *image.Gray16, transform func(color.Gray16) color.Gray16) *
 
 func otsuThresholdValue(img *image.Gray) uint8 {
 	hist := histogram.HistogramGray(img)
-	size := img.Bounds().Size()
-	totalNumberOfPixels := size.X * size.Y
+	return uint8(otsuLevel(hist[:]))
+}
 
+// otsuLevel returns the index of the last bin of the lower class found by Otsu's method.
+func otsuLevel(hist []uint64) int {
+	var totalNumberOfPixels int
 	var sumHist float64
 	for i, bin := range hist {
+		totalNumberOfPixels += int(bin)
 		sumHist += float64(uint64(i) * bin)
 	}
 
@@ -195,7 +227,7 @@ func otsuThresholdValue(img *image.Gray) uint8 {
 	var weightForeground int
 
 	maxVariance := 0.0
-	var thresh uint8
+	var thresh int
 	for i, bin := range hist {
 		weightBackground += int(bin)
 		if weightBackground == 0 {
@@ -215,7 +247,7 @@ func otsuThresholdValue(img *image.Gray) uint8 {
 
 		if variance > maxVariance {
 			maxVariance = variance
-			thresh = uint8(i)
+			thresh = i
 		}
 	}
 	return thresh
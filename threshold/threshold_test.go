@@ -8,6 +8,31 @@ import (
 	"github.com/ernyoke/imger/imgio"
 )
 
+// ---------------------------------Unit tests--------------------------------------
+
+func Test_OtsuLevel16(t *testing.T) {
+	gray := setupTestCaseOtsu(t)
+	// the 16 bit version of the image uses the same levels multiplied by 257
+	gray16 := image.NewGray16(gray.Bounds())
+	for i, v := range gray.Pix {
+		gray16.Pix[2*i], gray16.Pix[2*i+1] = v, v
+	}
+	expected := uint16(OtsuLevel(gray)) * 257
+	if level := OtsuLevel16(gray16); level != expected {
+		t.Errorf("Expected level %d - actual %d", expected, level)
+	}
+	thresh, err := OtsuThreshold16(gray16, ThreshBinary)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	thresh8, _ := OtsuThreshold(gray, ThreshBinary)
+	for i, v := range thresh8.Pix {
+		if thresh.Pix[2*i] != v {
+			t.Fatalf("Expected the same segmentation as the 8 bit version at %d", i)
+		}
+	}
+}
+
 // -----------------------------Acceptance tests------------------------------------
 func setupTestCaseGray(t *testing.T) *image.Gray {
 	path := "../res/girl.jpg"
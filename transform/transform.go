@@ -1,10 +1,12 @@
 package transform
 
 import (
+	"context"
 	"errors"
 	"image"
 	"math"
 
+	"github.com/ernyoke/imger/padding"
 	"github.com/ernyoke/imger/utils"
 )
 
@@ -15,21 +17,44 @@ import (
 //
 //	res, err := transform.RotateGray(img, 90.0, {512, 512}, true)
 func RotateGray(img *image.Gray, angle float64, anchor image.Point, resizeToFit bool) (*image.Gray, error) {
+	return RotateGrayWithBorder(img, angle, anchor, resizeToFit, padding.BorderConstant)
+}
+
+// RotateGrayWithBorder is RotateGray where the areas of the result which are outside of the original image are filled
+// according to the given border instead of being black.
+// Example of usage:
+//
+//	res, err := transform.RotateGrayWithBorder(img, 30.0, {512, 512}, false, padding.BorderReflect)
+func RotateGrayWithBorder(img *image.Gray, angle float64, anchor image.Point, resizeToFit bool,
+	border padding.Border) (*image.Gray, error) {
+	return RotateGrayWithBorderCtx(context.Background(), img, angle, anchor, resizeToFit, border, nil)
+}
+
+// RotateGrayWithBorderCtx is RotateGrayWithBorder which can be aborted using ctx. ctx.Err() is returned if the context
+// was cancelled.
+func RotateGrayWithBorderCtx(ctx context.Context, img *image.Gray, angle float64, anchor image.Point, resizeToFit bool,
+	border padding.Border, opts *utils.Options) (*image.Gray, error) {
 	size := img.Bounds().Size()
-	offset := img.Bounds().Min
 	if anchor.X < 0 || anchor.Y < 0 || anchor.X > size.X || anchor.Y > size.Y {
 		return nil, errors.New("invalid anchor position")
 	}
+	sampler, err := padding.NewGraySamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
 	radians := angleToRadians(angle)
 	newSize := size
 	if resizeToFit {
 		newSize = computeFitSize(size, radians)
 	}
 	result := image.NewGray(image.Rect(0, 0, newSize.X, newSize.Y))
-	utils.IteratePixels(newSize, func(x, y int) {
-		pixel := img.GrayAt(getOriginalPixelPosition(x+offset.X, y+offset.Y, radians, anchor, computeOffset(size, newSize)))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), newSize, func(x, y int) {
+		pixel := sampler.GrayAt(getOriginalPixelPosition(x, y, radians, anchor, computeOffset(size, newSize)))
 		result.SetGray(x, y, pixel)
 	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
@@ -38,23 +63,46 @@ func RotateGray(img *image.Gray, angle float64, anchor image.Point, resizeToFit
 // resized to fit in the area of the image.
 // Example of usage:
 //
-//	res, err := transform.RotateGray(img, 90.0, {512, 512}, true)
+//	res, err := transform.RotateRGBA(img, 90.0, {512, 512}, true)
 func RotateRGBA(img *image.RGBA, angle float64, anchor image.Point, resizeToFit bool) (*image.RGBA, error) {
+	return RotateRGBAWithBorder(img, angle, anchor, resizeToFit, padding.BorderConstant)
+}
+
+// RotateRGBAWithBorder is RotateRGBA where the areas of the result which are outside of the original image are filled
+// according to the given border instead of being black.
+// Example of usage:
+//
+//	res, err := transform.RotateRGBAWithBorder(img, 30.0, {512, 512}, false, padding.BorderReflect)
+func RotateRGBAWithBorder(img *image.RGBA, angle float64, anchor image.Point, resizeToFit bool,
+	border padding.Border) (*image.RGBA, error) {
+	return RotateRGBAWithBorderCtx(context.Background(), img, angle, anchor, resizeToFit, border, nil)
+}
+
+// RotateRGBAWithBorderCtx is RotateRGBAWithBorder which can be aborted using ctx. ctx.Err() is returned if the context
+// was cancelled.
+func RotateRGBAWithBorderCtx(ctx context.Context, img *image.RGBA, angle float64, anchor image.Point, resizeToFit bool,
+	border padding.Border, opts *utils.Options) (*image.RGBA, error) {
 	size := img.Bounds().Size()
-	offset := img.Bounds().Min
 	if anchor.X < 0 || anchor.Y < 0 || anchor.X > size.X || anchor.Y > size.Y {
 		return nil, errors.New("invalid anchor position")
 	}
+	sampler, err := padding.NewRGBASamplerWithFill(img, border, opts.FillOrDefault())
+	if err != nil {
+		return nil, err
+	}
 	radians := angleToRadians(angle)
 	newSize := size
 	if resizeToFit {
 		newSize = computeFitSize(size, radians)
 	}
 	result := image.NewRGBA(image.Rect(0, 0, newSize.X, newSize.Y))
-	utils.IteratePixels(newSize, func(x, y int) {
-		pixel := img.RGBAAt(getOriginalPixelPosition(x+offset.X, y+offset.Y, radians, anchor, computeOffset(size, newSize)))
+	err = utils.IteratePixelsCtx(ctx, opts.ExecutorOrDefault(), newSize, func(x, y int) {
+		pixel := sampler.RGBAAt(getOriginalPixelPosition(x, y, radians, anchor, computeOffset(size, newSize)))
 		result.SetRGBA(x, y, pixel)
 	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
@@ -1,11 +1,14 @@
 package transform
 
 import (
+	"context"
 	"image"
+	"image/color"
 	"math"
 	"testing"
 
 	"github.com/ernyoke/imger/imgio"
+	"github.com/ernyoke/imger/padding"
 	"github.com/ernyoke/imger/utils"
 )
 
@@ -182,6 +185,40 @@ func Test_GetOriginalPixelPosition45(t *testing.T) {
 	}
 }
 
+func Test_RotateGrayWithBorder(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 6, 4))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(10 + i)
+	}
+	opts := &utils.Options{Fill: color.Gray{Y: 1}}
+	constant, err := RotateGrayWithBorderCtx(context.Background(), gray, 30, image.Point{X: 3, Y: 2}, true,
+		padding.BorderConstant, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicated, err := RotateGrayWithBorder(gray, 30, image.Point{X: 3, Y: 2}, true, padding.BorderReplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filled := 0
+	for i, pix := range constant.Pix {
+		if pix == 1 {
+			filled++
+		} else if pix != replicated.Pix[i] {
+			t.Errorf("Expected: %d - actual: %d at %d", pix, replicated.Pix[i], i)
+		}
+		if replicated.Pix[i] < 10 {
+			t.Errorf("Expected a pixel of the image - actual: %d at %d", replicated.Pix[i], i)
+		}
+	}
+	if filled == 0 {
+		t.Error("Expected the corners of the result to be filled with the border color")
+	}
+	if _, err := RotateGrayWithBorder(gray, 30, image.Point{}, true, padding.Border(42)); err == nil {
+		t.Error("Expected an error for an unknown border")
+	}
+}
+
 // -----------------------------Acceptance tests------------------------------------
 func setupTestCaseGray(t *testing.T) *image.Gray {
 	path := "../res/building.jpg"
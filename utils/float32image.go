@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// Float32Image is an image with one or more channels of float32 samples. Unlike the 8 and 16 bit images of the image
+// package, the samples are not limited to a range, so it can hold intermediate results such as signed filter
+// responses. The bounds of the image always start at (0, 0).
+type Float32Image struct {
+	// Pix holds the samples of the image. The c-th channel of the pixel at {x, y} is at Pix[(y*Width+x)*Channels+c].
+	Pix []float32
+	// Width is the width of the image in pixels
+	Width int
+	// Height is the height of the image in pixels
+	Height int
+	// Channels is the number of samples for each pixel
+	Channels int
+}
+
+// NewFloat32Image creates a new Float32Image with the given size and number of channels. Every sample is 0.
+func NewFloat32Image(width int, height int, channels int) (*Float32Image, error) {
+	if width < 0 || height < 0 {
+		return nil, errors.New("negative image size")
+	}
+	if channels < 1 {
+		return nil, errors.New("invalid number of channels")
+	}
+	return &Float32Image{
+		Pix:      make([]float32, width*height*channels),
+		Width:    width,
+		Height:   height,
+		Channels: channels,
+	}, nil
+}
+
+// NewFloat32ImageFromGray creates a single channel Float32Image holding the values of a grayscale image.
+func NewFloat32ImageFromGray(img *image.Gray) *Float32Image {
+	size := img.Bounds().Size()
+	offset := img.Bounds().Min
+	res, _ := NewFloat32Image(size.X, size.Y, 1)
+	IteratePixels(size, func(x, y int) {
+		res.Pix[y*size.X+x] = float32(img.GrayAt(x+offset.X, y+offset.Y).Y)
+	})
+	return res
+}
+
+// Size returns the width and height of the image.
+func (f *Float32Image) Size() image.Point {
+	return image.Point{X: f.Width, Y: f.Height}
+}
+
+// At returns the value of the c-th channel of the pixel at {x, y}.
+func (f *Float32Image) At(x int, y int, c int) float32 {
+	return f.Pix[(y*f.Width+x)*f.Channels+c]
+}
+
+// Set sets the value of the c-th channel of the pixel at {x, y}.
+func (f *Float32Image) Set(x int, y int, c int, value float32) {
+	f.Pix[(y*f.Width+x)*f.Channels+c] = value
+}
+
+// ConversionMode decides how the unbounded values of a Float32Image are mapped to the [0, 255] range of a grayscale
+// image.
+type ConversionMode int
+
+const (
+	// ConvertNormalize - the values are scaled linearly, so that the minimum becomes 0 and the maximum becomes 255
+	ConvertNormalize ConversionMode = iota
+	// ConvertClamp - the values are truncated and limited to [0, 255]
+	ConvertClamp
+	// ConvertAbs - the absolute values are truncated and limited to [0, 255]
+	ConvertAbs
+)
+
+// ToGray converts a single channel Float32Image to a grayscale image using the given conversion mode.
+// Example of usage:
+//
+//	gray, err := response.ToGray(utils.ConvertAbs)
+func (f *Float32Image) ToGray(mode ConversionMode) (*image.Gray, error) {
+	if f.Channels != 1 {
+		return nil, errors.New("the image has more than one channel")
+	}
+	return f.ChannelToGray(0, mode)
+}
+
+// ChannelToGray converts the c-th channel of a Float32Image to a grayscale image using the given conversion mode.
+func (f *Float32Image) ChannelToGray(c int, mode ConversionMode) (*image.Gray, error) {
+	if c < 0 || c >= f.Channels {
+		return nil, errors.New("invalid channel")
+	}
+	var convert func(float32) uint8
+	switch mode {
+	case ConvertNormalize:
+		min, max := f.channelRange(c)
+		scale := 0.0
+		if max > min {
+			scale = float64(MaxUint8) / (max - min)
+		}
+		convert = func(v float32) uint8 {
+			return uint8(ClampF64(math.Round((float64(v)-min)*scale), MinUint8, float64(MaxUint8)))
+		}
+	case ConvertClamp:
+		convert = func(v float32) uint8 {
+			return uint8(ClampF64(float64(v), MinUint8, float64(MaxUint8)))
+		}
+	case ConvertAbs:
+		convert = func(v float32) uint8 {
+			return uint8(ClampF64(math.Abs(float64(v)), MinUint8, float64(MaxUint8)))
+		}
+	default:
+		return nil, errors.New("invalid conversion mode")
+	}
+	res := image.NewGray(image.Rect(0, 0, f.Width, f.Height))
+	IteratePixels(f.Size(), func(x, y int) {
+		res.Pix[y*res.Stride+x] = convert(f.At(x, y, c))
+	})
+	return res, nil
+}
+
+// -------------------------------------------------------------------------------------------------------
+func (f *Float32Image) channelRange(c int) (float64, float64) {
+	if f.Width == 0 || f.Height == 0 {
+		return 0, 0
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for i := c; i < len(f.Pix); i += f.Channels {
+		v := float64(f.Pix[i])
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"image"
+	"testing"
+)
+
+// ---------------------------------Unit tests------------------------------------
+func newTestFloat32Image() *Float32Image {
+	img, _ := NewFloat32Image(3, 2, 1)
+	copy(img.Pix, []float32{-100, -0.5, 0, 100.7, 300, 200})
+	return img
+}
+
+func Test_Float32Image_ToGray(t *testing.T) {
+	cases := []struct {
+		mode     ConversionMode
+		expected []uint8
+	}{
+		{ConvertClamp, []uint8{0, 0, 0, 100, 255, 200}},
+		{ConvertAbs, []uint8{100, 0, 0, 100, 255, 200}},
+		{ConvertNormalize, []uint8{0, 63, 64, 128, 255, 191}},
+	}
+	for _, c := range cases {
+		actual, err := newTestFloat32Image().ToGray(c.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := &image.Gray{Pix: c.expected, Stride: 3, Rect: image.Rect(0, 0, 3, 2)}
+		CompareGrayImages(t, expected, actual)
+	}
+}
+
+func Test_Float32Image_ToGray_Errors(t *testing.T) {
+	img, _ := NewFloat32Image(2, 2, 2)
+	if _, err := img.ToGray(ConvertClamp); err == nil {
+		t.Error("Expected an error for a multi channel image")
+	}
+	if _, err := img.ChannelToGray(2, ConvertClamp); err == nil {
+		t.Error("Expected an error for an invalid channel")
+	}
+	if _, err := img.ChannelToGray(1, ConversionMode(10)); err == nil {
+		t.Error("Expected an error for an invalid conversion mode")
+	}
+	if _, err := NewFloat32Image(2, 2, 0); err == nil {
+		t.Error("Expected an error for 0 channels")
+	}
+}
+
+func Test_NewFloat32ImageFromGray(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 10)
+	}
+	sub := gray.SubImage(image.Rect(1, 1, 3, 4)).(*image.Gray)
+	img := NewFloat32ImageFromGray(sub)
+	if img.Width != 2 || img.Height != 3 {
+		t.Fatalf("Expected size: 2x3 - actual size: %dx%d", img.Width, img.Height)
+	}
+	actual, _ := img.ToGray(ConvertClamp)
+	CompareGrayImages(t, sub, actual)
+}
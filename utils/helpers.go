@@ -3,53 +3,63 @@ package utils
 import (
 	"image"
 	"image/color"
-	"os"
 )
 
-func init() {
-	// IteratePixels can optionally run using parallel goroutines.
-	if os.Getenv("IMGER_ENABLE_PARALLEL_FOR_EACH") == "true" {
-		IteratePixels = ParallelForEachPixel
-	}
-}
-
-var IteratePixels = func(size image.Point, f func(x, y int)) {
-	for y := 0; y < size.Y; y++ {
-		for x := 0; x < size.X; x++ {
-			f(x, y)
-		}
-	}
+// IteratePixels loops through every [x, y] position of an area with the given size and calls f for each of them, row
+// by row on the calling goroutine. See IteratePixelsWith to process the rows using an Executor.
+func IteratePixels(size image.Point, f func(x, y int)) {
+	IteratePixelsWith(Sequential, size, f)
 }
 
 // ForEachPixel iterates through each pixel of an image and calls f, supplying the color
 // and offset-compensated position of that pixel.
 func ForEachPixel(img image.Image, f func(pixel color.Color, x, y int)) {
+	ForEachPixelWith(Sequential, img, f)
+}
+
+// ForEachPixelWith is ForEachPixel where the rows are distributed by the executor e.
+func ForEachPixelWith(e Executor, img image.Image, f func(pixel color.Color, x, y int)) {
 	offset := img.Bounds().Min
-	IteratePixels(img.Bounds().Size(), func(x, y int) {
+	IteratePixelsWith(e, img.Bounds().Size(), func(x, y int) {
 		f(img.At(x+offset.X, y+offset.Y), x, y)
 	})
 }
 
 // ForEachGrayPixel is ForEachPixel but for image.Gray images.
 func ForEachGrayPixel(img *image.Gray, f func(pixel color.Gray, x, y int)) {
+	ForEachGrayPixelWith(Sequential, img, f)
+}
+
+// ForEachGrayPixelWith is ForEachPixelWith but for image.Gray images.
+func ForEachGrayPixelWith(e Executor, img *image.Gray, f func(pixel color.Gray, x, y int)) {
 	offset := img.Bounds().Min
-	IteratePixels(img.Bounds().Size(), func(x, y int) {
+	IteratePixelsWith(e, img.Bounds().Size(), func(x, y int) {
 		f(img.GrayAt(x+offset.X, y+offset.Y), x, y)
 	})
 }
 
 // ForEachGray16Pixel is ForEachPixel but for image.Gray16 images.
 func ForEachGray16Pixel(img *image.Gray16, f func(pixel color.Gray16, x, y int)) {
+	ForEachGray16PixelWith(Sequential, img, f)
+}
+
+// ForEachGray16PixelWith is ForEachPixelWith but for image.Gray16 images.
+func ForEachGray16PixelWith(e Executor, img *image.Gray16, f func(pixel color.Gray16, x, y int)) {
 	offset := img.Bounds().Min
-	IteratePixels(img.Bounds().Size(), func(x, y int) {
+	IteratePixelsWith(e, img.Bounds().Size(), func(x, y int) {
 		f(img.Gray16At(x+offset.X, y+offset.Y), x, y)
 	})
 }
 
 // ForEachRGBAPixel is ForEachPixel but for image.RGBA images.
 func ForEachRGBAPixel(img *image.RGBA, f func(pixel color.RGBA, x, y int)) {
+	ForEachRGBAPixelWith(Sequential, img, f)
+}
+
+// ForEachRGBAPixelWith is ForEachPixelWith but for image.RGBA images.
+func ForEachRGBAPixelWith(e Executor, img *image.RGBA, f func(pixel color.RGBA, x, y int)) {
 	offset := img.Bounds().Min
-	IteratePixels(img.Bounds().Size(), func(x, y int) {
+	IteratePixelsWith(e, img.Bounds().Size(), func(x, y int) {
 		f(img.RGBAAt(x+offset.X, y+offset.Y), x, y)
 	})
 }
@@ -0,0 +1,36 @@
+package utils
+
+import "image/color"
+
+// Options holds the optional parameters of the Ctx variants of the operations of the library. A nil *Options is
+// valid and uses the default of every field.
+type Options struct {
+	// Executor processes the rows of the images, Sequential if nil
+	Executor Executor
+	// Fill is the color of the pixels outside of the image for BorderConstant, black if nil
+	Fill color.Color
+}
+
+// ExecutorOrDefault returns the executor of the options, or Sequential if o is nil or does not set one.
+// Example of usage:
+//
+//	opts := &utils.Options{Executor: utils.NewParallelExecutor(4)}
+//	res, err := blur.GaussianBlurGrayCtx(ctx, img, 5, 2, padding.BorderReflect, opts)
+func (o *Options) ExecutorOrDefault() Executor {
+	if o == nil || o.Executor == nil {
+		return Sequential
+	}
+	return o.Executor
+}
+
+// FillOrDefault returns the fill color of the options, or color.Transparent (black) if o is nil or does not set one.
+// Example of usage:
+//
+//	opts := &utils.Options{Fill: color.White}
+//	res, err := convolution.ConvolveGrayCtx(ctx, img, kernel, anchor, padding.BorderConstant, opts)
+func (o *Options) FillOrDefault() color.Color {
+	if o == nil || o.Fill == nil {
+		return color.Transparent
+	}
+	return o.Fill
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"image/color"
+	"testing"
+)
+
+// ---------------------------------Unit tests------------------------------------
+
+func Test_Options_ExecutorOrDefault(t *testing.T) {
+	var nilOptions *Options
+	if e := nilOptions.ExecutorOrDefault(); e != Sequential {
+		t.Errorf("Expected the sequential executor for nil options - actual: %v", e)
+	}
+	if e := (&Options{}).ExecutorOrDefault(); e != Sequential {
+		t.Errorf("Expected the sequential executor for empty options - actual: %v", e)
+	}
+	parallel := NewParallelExecutor(2)
+	if e := (&Options{Executor: parallel}).ExecutorOrDefault(); e != parallel {
+		t.Errorf("Expected the executor of the options - actual: %v", e)
+	}
+}
+
+func Test_Options_FillOrDefault(t *testing.T) {
+	var nilOptions *Options
+	if fill := nilOptions.FillOrDefault(); fill != color.Transparent {
+		t.Errorf("Expected black for nil options - actual: %v", fill)
+	}
+	if fill := (&Options{}).FillOrDefault(); fill != color.Transparent {
+		t.Errorf("Expected black for empty options - actual: %v", fill)
+	}
+	if fill := (&Options{Fill: color.White}).FillOrDefault(); fill != color.White {
+		t.Errorf("Expected the fill color of the options - actual: %v", fill)
+	}
+}
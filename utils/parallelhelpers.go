@@ -1,44 +1,132 @@
 package utils
 
 import (
+	"context"
 	"image"
-	"math"
 	"runtime"
 	"sync"
+	"sync/atomic"
 )
 
-// ParallelForEachPixel loops through the image and calls f functions for each [x, y] position.
-// The image is divided into N * N blocks, where N is the number of available processor threads. For each block a
-// parallel Goroutine is started.
-func ParallelForEachPixel(size image.Point, f func(x int, y int)) {
-	procs := runtime.GOMAXPROCS(0)
+// Executor decides how the rows of an image are processed. The rows [0, height) are divided into bands of
+// consecutive rows and f is called once for every band. Depending on the implementation the bands may be processed
+// concurrently, so f has to be safe to call from multiple goroutines for disjoint bands.
+type Executor interface {
+	IterateRows(height int, f func(startY, endY int))
+}
+
+// Sequential is an Executor which processes every row on the calling goroutine.
+var Sequential Executor = sequentialExecutor{}
+
+type sequentialExecutor struct{}
+
+// IterateRows calls f once with the full range of rows.
+func (sequentialExecutor) IterateRows(height int, f func(startY, endY int)) {
+	if height > 0 {
+		f(0, height)
+	}
+}
+
+// bandsPerWorker is the number of row bands created for every worker, so that a slow band does not keep the other
+// workers idle.
+const bandsPerWorker = 4
+
+type parallelExecutor struct {
+	workers int
+}
+
+// NewParallelExecutor returns an Executor which divides the rows into bands and processes them using a pool of at most
+// "workers" goroutines. If workers is 0 or negative, the number of available processor threads (GOMAXPROCS) is used.
+// Example of usage:
+//
+//	utils.IteratePixelsWith(utils.NewParallelExecutor(4), size, func(x, y int) { ... })
+func NewParallelExecutor(workers int) Executor {
+	return &parallelExecutor{workers: workers}
+}
+
+// IterateRows processes the row bands concurrently and returns after every band was processed.
+func (p *parallelExecutor) IterateRows(height int, f func(startY, endY int)) {
+	if height <= 0 {
+		return
+	}
+	workers := p.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	bands := workers * bandsPerWorker
+	if bands > height {
+		bands = height
+	}
+	if workers > bands {
+		workers = bands
+	}
+	bandHeight := (height + bands - 1) / bands
+	bands = (height + bandHeight - 1) / bandHeight
+	if workers == 1 {
+		f(0, height)
+		return
+	}
+
+	var next int64 = -1
 	var waitGroup sync.WaitGroup
-	for i := 0; i < procs; i++ {
-		startX := i * int(math.Floor(float64(size.X)/float64(procs)))
-		var endX int
-		if i < procs-1 {
-			endX = (i + 1) * int(math.Floor(float64(size.X)/float64(procs)))
-		} else {
-			endX = size.X
-		}
-		for j := 0; j < procs; j++ {
-			startY := j * int(math.Floor(float64(size.Y)/float64(procs)))
-			var endY int
-			if j < procs-1 {
-				endY = (j + 1) * int(math.Floor(float64(size.Y)/float64(procs)))
-			} else {
-				endY = size.Y
-			}
-			waitGroup.Add(1)
-			go func(sX int, eX int, sY int, eY int) {
-				defer waitGroup.Done()
-				for x := sX; x < eX; x++ {
-					for y := sY; y < eY; y++ {
-						f(x, y)
-					}
+	waitGroup.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer waitGroup.Done()
+			for {
+				band := int(atomic.AddInt64(&next, 1))
+				if band >= bands {
+					return
 				}
-			}(startX, endX, startY, endY)
-			waitGroup.Wait()
+				startY := band * bandHeight
+				endY := startY + bandHeight
+				if endY > height {
+					endY = height
+				}
+				f(startY, endY)
+			}
+		}()
+	}
+	waitGroup.Wait()
+}
+
+// IteratePixelsWith loops through every [x, y] position of an area with the given size and calls f for each of them.
+// The rows are distributed by the executor e.
+func IteratePixelsWith(e Executor, size image.Point, f func(x, y int)) {
+	if size.X <= 0 {
+		return
+	}
+	e.IterateRows(size.Y, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			for x := 0; x < size.X; x++ {
+				f(x, y)
+			}
 		}
+	})
+}
+
+// ParallelForEachPixel loops through the image and calls f functions for each [x, y] position.
+// The rows of the image are divided into bands which are processed by a pool of goroutines, one for each available
+// processor thread. See NewParallelExecutor to control the number of goroutines.
+func ParallelForEachPixel(size image.Point, f func(x int, y int)) {
+	IteratePixelsWith(NewParallelExecutor(0), size, f)
+}
+
+// IteratePixelsCtx is IteratePixelsWith which stops early if ctx is cancelled. The context is checked before each
+// row, so a cancelled context skips the rows which were not processed yet. Returns ctx.Err() if the context was
+// cancelled, in which case the caller should discard any partial result.
+func IteratePixelsCtx(ctx context.Context, e Executor, size image.Point, f func(x, y int)) error {
+	if size.X > 0 {
+		e.IterateRows(size.Y, func(startY, endY int) {
+			for y := startY; y < endY; y++ {
+				if ctx.Err() != nil {
+					return
+				}
+				for x := 0; x < size.X; x++ {
+					f(x, y)
+				}
+			}
+		})
 	}
+	return ctx.Err()
 }
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"context"
 	"image"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func Test_ParallelForEachPixel(t *testing.T) {
@@ -28,3 +32,75 @@ func Test_ParallelForEachPixel(t *testing.T) {
 		}
 	}
 }
+
+func Test_ParallelExecutor_VisitsEveryPixelOnce(t *testing.T) {
+	sizes := []image.Point{{X: 1, Y: 1}, {X: 3, Y: 2}, {X: 17, Y: 5}, {X: 64, Y: 131}, {X: 0, Y: 10}, {X: 10, Y: 0}}
+	for _, workers := range []int{0, 1, 2, 3, 8, 100} {
+		for _, size := range sizes {
+			var counts []int32
+			if size.X > 0 && size.Y > 0 {
+				counts = make([]int32, size.X*size.Y)
+			}
+			IteratePixelsWith(NewParallelExecutor(workers), size, func(x, y int) {
+				atomic.AddInt32(&counts[y*size.X+x], 1)
+			})
+			for i, c := range counts {
+				if c != 1 {
+					t.Errorf("workers: %d size: %v - pixel %d visited %d times", workers, size, i, c)
+				}
+			}
+		}
+	}
+}
+
+func Test_ParallelExecutor_BoundedWorkers(t *testing.T) {
+	const workers = 3
+	var running, maxRunning int32
+	var mu sync.Mutex
+	NewParallelExecutor(workers).IterateRows(200, func(startY, endY int) {
+		current := atomic.AddInt32(&running, 1)
+		mu.Lock()
+		if current > maxRunning {
+			maxRunning = current
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+	if maxRunning > workers {
+		t.Errorf("Expected at most %d concurrent bands - actual: %d", workers, maxRunning)
+	}
+	if maxRunning < 2 {
+		t.Errorf("Expected the bands to be processed concurrently - actual concurrent bands: %d", maxRunning)
+	}
+}
+
+func Test_SequentialExecutor(t *testing.T) {
+	calls := 0
+	Sequential.IterateRows(42, func(startY, endY int) {
+		calls++
+		if startY != 0 || endY != 42 {
+			t.Errorf("Expected band [0, 42) - actual: [%d, %d)", startY, endY)
+		}
+	})
+	if calls != 1 {
+		t.Errorf("Expected 1 call - actual: %d", calls)
+	}
+}
+
+func Test_IteratePixelsCtx_StopsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rows := 0
+	err := IteratePixelsCtx(ctx, Sequential, image.Point{X: 1, Y: 100}, func(x, y int) {
+		rows++
+		if y == 9 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("Expected error: %v - actual error: %v", context.Canceled, err)
+	}
+	if rows != 10 {
+		t.Errorf("Expected 10 processed rows - actual: %d", rows)
+	}
+}